@@ -4,7 +4,9 @@ Job running asynchronously to update character list
 package jobs
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -14,13 +16,24 @@ import (
 
 // StartUpdateCharacterListJob this function fork a nnew goroutine for periodically check for new character
 // in marvel api and start update the character list
+// the list is cached for ttl, onUpdate (if set) receives the slim records of the characters whenever the list got updated
 func StartUpdateCharacterListJob(shutdown <-chan struct{},
 	tick time.Duration,
 	c cacher.Cacher,
 	cacheKey string,
+	ttl time.Duration,
 	marvelAPI *marvel.API,
+	onUpdate func([]*marvel.MarvelCharacter),
 ) {
 	go func() {
+		// the sync is not user facing, it must not eat the quota reserved for users
+		ctx, cancel := context.WithCancel(marvel.WithLowPriority(context.Background()))
+		defer cancel()
+		go func() {
+			// abort in-flight marvel requests as soon as shutdown is signaled
+			<-shutdown
+			cancel()
+		}()
 		ticker := time.NewTicker(tick)
 		defer ticker.Stop()
 		for {
@@ -29,16 +42,24 @@ func StartUpdateCharacterListJob(shutdown <-chan struct{},
 				// got a shutdown signal, quit the process
 				return
 			case <-ticker.C:
-				updateMarvelCharacterList(c, cacheKey, marvelAPI)
+				updateMarvelCharacterList(ctx, c, cacheKey, ttl, marvelAPI, onUpdate)
 			}
 		}
 	}()
 }
 
-func updateMarvelCharacterList(c cacher.Cacher,
+func updateMarvelCharacterList(ctx context.Context,
+	c cacher.Cacher,
 	cacheKey string,
-	marvelAPI *marvel.API) {
-	shouldUpdate, err := checkMarvelUpdate(c, cacheKey, marvelAPI)
+	ttl time.Duration,
+	marvelAPI *marvel.API,
+	onUpdate func([]*marvel.MarvelCharacter)) {
+	if quota, ok := marvelAPI.Quota(); ok && quota.NearLimit {
+		// keep the remaining calls for user requests, the list will be checked on next tick
+		log.Println("[AsyncJob]Skip Marvel Update, quota remaining", quota.Remaining)
+		return
+	}
+	shouldUpdate, err := checkMarvelUpdate(ctx, c, cacheKey, marvelAPI)
 	if err != nil {
 		// if error occur we shouldn't interupt the process
 		// just log for monitoring/alerting
@@ -47,22 +68,35 @@ func updateMarvelCharacterList(c cacher.Cacher,
 	}
 	if shouldUpdate {
 		// get the new list and update cache
-		list, err := marvelAPI.GetAllCharacters()
+		characters, err := marvelAPI.GetAllCharacterRecordsWithContext(ctx)
 		if err != nil {
 			// just log for monitoring/alerting
 			log.Println("[AsyncJob]Check Marvel Update got error", err)
 			return
 		}
+		list := make([]int, 0, len(characters))
+		for _, character := range characters {
+			list = append(list, character.ID)
+		}
 		b, err := json.Marshal(&list)
+		var value string
+		if err == nil {
+			// the list is stored with its sync time so that readers can tell how fresh it is
+			value, err = cacher.EncodeEntry(b, time.Now())
+		}
 		if err != nil {
 			// just log for monitoring/alerting
 			log.Println("[AsyncJob]Check Marvel Update got error", err)
+			return
+		}
+		c.SetWithTTL(cacheKey, value, ttl)
+		if onUpdate != nil {
+			onUpdate(characters)
 		}
-		c.Set(cacheKey, string(b))
 	}
 }
 
-func checkMarvelUpdate(c cacher.Cacher, cacheKey string, marvelAPI *marvel.API) (bool, error) {
+func checkMarvelUpdate(ctx context.Context, c cacher.Cacher, cacheKey string, marvelAPI *marvel.API) (bool, error) {
 	// get from cache, if it doesn't got cached, request to get the list
 	v, found := c.Get(cacheKey)
 	if !found {
@@ -70,14 +104,23 @@ func checkMarvelUpdate(c cacher.Cacher, cacheKey string, marvelAPI *marvel.API)
 	}
 	var list []int
 	err := json.Unmarshal([]byte(v), &list)
+	if entry, ok := cacher.DecodeEntry(v); ok {
+		// the server may have cached the list with another codec
+		codec, found := cacher.CodecByName(entry.Codec)
+		if found {
+			err = codec.Unmarshal(entry.Value, &list)
+		} else {
+			err = fmt.Errorf("unknown codec %s", entry.Codec)
+		}
+	}
 	if err != nil {
 		// some how we store a corrupted data?
 		// log error
-		log.Println("[AsyncJob]Cache a corrupted character list", v)
+		log.Println("[AsyncJob]Cache a corrupted character list", err)
 		return true, nil
 	}
 	// get first api of marvel to get the total data
-	_, total, err := marvelAPI.DoGetListCharacters(0, 1)
+	_, total, err := marvelAPI.DoGetListCharactersWithContext(ctx, 0, 1)
 	if err != nil {
 		return false, err
 	}
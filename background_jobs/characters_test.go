@@ -1,8 +1,10 @@
 package jobs
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/hauxe/xendit_pratice/cacher"
 	"github.com/hauxe/xendit_pratice/marvel"
@@ -20,8 +22,8 @@ func TestUpdateMarvelCharacterList(t *testing.T) {
 		c.Set(cacheKey, val)
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
-		updateMarvelCharacterList(c, cacheKey, api)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		updateMarvelCharacterList(context.Background(), c, cacheKey, 0, api, nil)
 
 		list, ok := c.Get(cacheKey)
 		require.True(t, ok)
@@ -35,16 +37,57 @@ func TestUpdateMarvelCharacterList(t *testing.T) {
 		c.Set(cacheKey, val)
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
-		updateMarvelCharacterList(c, cacheKey, api)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		updateMarvelCharacterList(context.Background(), c, cacheKey, 0, api, nil)
 
 		v, ok := c.Get(cacheKey)
 		require.True(t, ok)
+		entry, ok := cacher.DecodeEntry(v)
+		require.True(t, ok)
 		var list []int
-		err = json.Unmarshal([]byte(v), &list)
+		err = json.Unmarshal(entry.Value, &list)
 		require.NoError(t, err)
 		require.GreaterOrEqual(t, len(list), 1000)
 	})
+	t.Run("on_update", func(t *testing.T) {
+		t.Parallel()
+		c := cacher.NewCacher()
+		cacheKey := "test_get_all_character"
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		var characters []*marvel.MarvelCharacter
+		updateMarvelCharacterList(context.Background(), c, cacheKey, 0, api, func(records []*marvel.MarvelCharacter) {
+			characters = records
+		})
+
+		v, ok := c.Get(cacheKey)
+		require.True(t, ok)
+		entry, ok := cacher.DecodeEntry(v)
+		require.True(t, ok)
+		require.Equal(t, "[1011334,1011335,1011336]", string(entry.Value))
+		require.Len(t, characters, 3)
+		require.Equal(t, "1-D Man", characters[0].Name)
+	})
+	t.Run("quota_near_limit", func(t *testing.T) {
+		t.Parallel()
+		c := cacher.NewCacher()
+		cacheKey := "test_get_all_character"
+		val := "[0,1,2]"
+		c.Set(cacheKey, val)
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "",
+			marvel.WithScheme("http"),
+			marvel.WithDailyQuota(100, 100),
+		)
+		updateMarvelCharacterList(context.Background(), c, cacheKey, 0, api, nil)
+
+		list, ok := c.Get(cacheKey)
+		require.True(t, ok)
+		require.Equal(t, val, list)
+		require.Zero(t, api.Stats().Requests)
+	})
 }
 
 func TestCheckMarvelUpdate(t *testing.T) {
@@ -53,7 +96,7 @@ func TestCheckMarvelUpdate(t *testing.T) {
 		t.Parallel()
 		c := cacher.NewCacher()
 		cacheKey := "test_get_all_character"
-		shouldUpdate, err := checkMarvelUpdate(c, cacheKey, nil)
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, nil)
 		require.NoError(t, err)
 		require.True(t, shouldUpdate)
 	})
@@ -62,7 +105,7 @@ func TestCheckMarvelUpdate(t *testing.T) {
 		c := cacher.NewCacher()
 		cacheKey := "test_get_all_character"
 		c.Set(cacheKey, "invalid json")
-		shouldUpdate, err := checkMarvelUpdate(c, cacheKey, nil)
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, nil)
 		require.NoError(t, err)
 		require.True(t, shouldUpdate)
 	})
@@ -73,8 +116,8 @@ func TestCheckMarvelUpdate(t *testing.T) {
 		c.Set(cacheKey, "[0,1,2]")
 		testServer, err := test.NewTestServer(test.NewMockHandler("invalid json"))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
-		shouldUpdate, err := checkMarvelUpdate(c, cacheKey, api)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, api)
 		require.Error(t, err)
 		require.False(t, shouldUpdate)
 	})
@@ -85,11 +128,42 @@ func TestCheckMarvelUpdate(t *testing.T) {
 		c.Set(cacheKey, "[0,1,2]")
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
-		shouldUpdate, err := checkMarvelUpdate(c, cacheKey, api)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, api)
+		require.NoError(t, err)
+		require.False(t, shouldUpdate)
+	})
+	t.Run("list_unchanged_entry", func(t *testing.T) {
+		t.Parallel()
+		c := cacher.NewCacher()
+		cacheKey := "test_get_all_character"
+		value, err := cacher.EncodeEntry([]byte("[0,1,2]"), time.Now())
+		require.NoError(t, err)
+		c.Set(cacheKey, value)
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, api)
 		require.NoError(t, err)
 		require.False(t, shouldUpdate)
 	})
+	t.Run("list_unchanged_codec", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		// the server caches the list with its codec
+		for _, codec := range []cacher.Codec{cacher.BinaryCodec, cacher.GobCodec} {
+			c := cacher.NewCacher()
+			cacheKey := "test_get_all_character"
+			b, err := codec.Marshal(&[]int{0, 1, 2})
+			require.NoError(t, err)
+			c.Set(cacheKey, cacher.Entry{StoredAt: time.Now(), Codec: codec.Name(), Value: b}.Encode())
+			shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, api)
+			require.NoError(t, err, codec.Name())
+			require.False(t, shouldUpdate, codec.Name())
+		}
+	})
 	t.Run("list_changed", func(t *testing.T) {
 		t.Parallel()
 		c := cacher.NewCacher()
@@ -97,8 +171,8 @@ func TestCheckMarvelUpdate(t *testing.T) {
 		c.Set(cacheKey, "[0,1,2]")
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
-		shouldUpdate, err := checkMarvelUpdate(c, cacheKey, api)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		shouldUpdate, err := checkMarvelUpdate(context.Background(), c, cacheKey, api)
 		require.NoError(t, err)
 		require.True(t, shouldUpdate)
 	})
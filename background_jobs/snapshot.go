@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+)
+
+// StartSnapshotJob this function fork a new goroutine for periodically saving the snapshot of the cache to path
+// so that a restarted server doesn't start with a cold cache
+// the returned channel is closed once the job quit, a save in progress is done by then
+func StartSnapshotJob(shutdown <-chan struct{},
+	tick time.Duration,
+	c cacher.Cacher,
+	path string,
+) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-shutdown:
+				// got a shutdown signal, quit the process
+				return
+			case <-ticker.C:
+				saveSnapshot(c, path)
+			}
+		}
+	}()
+	return done
+}
+
+func saveSnapshot(c cacher.Cacher, path string) {
+	if err := cacher.SaveSnapshot(c, path); err != nil {
+		// just log for monitoring/alerting
+		log.Println("[AsyncJob]Save Cache Snapshot got error", err)
+	}
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSnapshotJob(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	c := cacher.NewCacher()
+	c.Set("key", "value")
+	shutdown := make(chan struct{})
+	done := StartSnapshotJob(shutdown, time.Millisecond, c, path)
+	defer func() {
+		close(shutdown)
+		// a save may still be running, the directory is removed once the job quit
+		<-done
+	}()
+
+	restored := cacher.NewCacher()
+	require.Eventually(t, func() bool {
+		n, err := cacher.LoadSnapshot(restored, path)
+		return err == nil && n == 1
+	}, time.Second, 5*time.Millisecond)
+	v, ok := restored.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "value", v)
+}
@@ -3,36 +3,311 @@ Implement a very simple cache for demo
 */
 package cacher
 
-import "sync"
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type Cacher interface {
 	Get(string) (string, bool)
+	// Set set the key with the default ttl of the cache
 	Set(string, string)
+	// SetWithTTL set the key expiring after ttl, a ttl <= 0 never expires
+	SetWithTTL(string, string, time.Duration)
+	// Delete removes the key, it reports whether the key was cached
+	Delete(string) bool
+	// DeletePrefix removes all the keys starting with prefix and returns how many were removed
+	DeletePrefix(string) int
+	// Clear removes all the keys
+	Clear()
+}
+
+// Option configures the cache created by NewCacher
+type Option func(*cache)
+
+// WithDefaultTTL sets the ttl of the keys set by Set, by default keys never expire
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *cache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithCleanupInterval starts a background janitor removing the expired keys every interval
+// expired keys are never returned anyway, the janitor only frees their memory
+// the janitor is stopped by Close
+func WithCleanupInterval(interval time.Duration) Option {
+	return func(c *cache) {
+		c.cleanupInterval = interval
+	}
+}
+
+type entry struct {
+	value string
+	// expiresAt is zero for entries that never expire
+	expiresAt time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
 }
 
 type cache struct {
-	storage map[string]string
+	// the counters are accessed atomically, they are kept first for 64-bit alignment
+	hits    uint64
+	misses  uint64
+	sets    uint64
+	evicted uint64
+	expired uint64
+
+	storage map[string]entry
 	lock    sync.RWMutex
+
+	// bytes is the approximate size of the stored keys and values
+	bytes int64
+
+	defaultTTL      time.Duration
+	cleanupInterval time.Duration
+	stop            chan struct{}
+	stopOnce        sync.Once
+	// now is replaceable for tests
+	now func() time.Time
+
+	maxEntries int
+	maxBytes   int64
+	// policy is nil for an unbounded cache
+	policy    EvictionPolicy
+	newPolicy func() EvictionPolicy
 }
 
 // NewCacher create a cache instance
-func NewCacher() Cacher {
-	return &cache{
-		storage: make(map[string]string),
+func NewCacher(opts ...Option) Cacher {
+	c := newCache(opts...)
+	if c.cleanupInterval > 0 {
+		go c.janitor()
+	}
+	return c
+}
+
+// newCache creates a cache without starting its janitor
+func newCache(opts ...Option) *cache {
+	c := &cache{
+		storage: make(map[string]entry),
+		stop:    make(chan struct{}),
+		now:     time.Now,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.policy == nil && c.newPolicy != nil {
+		c.policy = c.newPolicy()
+	}
+	if c.policy == nil && (c.maxEntries > 0 || c.maxBytes > 0) {
+		c.policy = NewLRUPolicy()
+	}
+	return c
 }
 
 // Get get cache from key
 func (c *cache) Get(key string) (string, bool) {
 	c.lock.RLock()
-	defer c.lock.RUnlock()
-	v, ok := c.storage[key]
-	return v, ok
+	e, ok := c.storage[key]
+	now := c.clock()
+	if ok && !e.expired(now) && c.policy != nil {
+		c.policy.Access(key)
+	}
+	c.lock.RUnlock()
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return "", false
+	}
+	if e.expired(now) {
+		atomic.AddUint64(&c.misses, 1)
+		c.lock.Lock()
+		// the key may have been set again meanwhile
+		if e, ok := c.storage[key]; ok && e.expired(now) {
+			c.remove(key, e)
+			atomic.AddUint64(&c.expired, 1)
+		}
+		c.lock.Unlock()
+		return "", false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return e.value, true
 }
 
 // Set set cache key with value
 func (c *cache) Set(key string, value string) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL set cache key with value expiring after ttl
+func (c *cache) SetWithTTL(key string, value string, ttl time.Duration) {
+	atomic.AddUint64(&c.sets, 1)
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = c.clock().Add(ttl)
+	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.storage[key] = value
+	old, found := c.storage[key]
+	if found {
+		// drop the old value but keep the key history in the policy
+		delete(c.storage, key)
+		c.bytes -= entrySize(key, old)
+	}
+	size := entrySize(key, e)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		// it would evict everything and still not fit
+		if found && c.policy != nil {
+			c.policy.Remove(key)
+		}
+		atomic.AddUint64(&c.evicted, 1)
+		return
+	}
+	if c.evict(key, size) {
+		found = false
+	}
+	c.storage[key] = e
+	c.bytes += size
+	if c.policy != nil {
+		if found {
+			// an overwrite is a use of the key
+			c.policy.Access(key)
+		} else {
+			c.policy.Add(key)
+		}
+	}
+}
+
+// Delete delete cache key
+func (c *cache) Delete(key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.storage[key]
+	if !ok {
+		return false
+	}
+	c.remove(key, e)
+	return !e.expired(c.clock())
+}
+
+// DeletePrefix delete all cache keys starting with prefix
+func (c *cache) DeletePrefix(prefix string) int {
+	now := c.clock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	n := 0
+	for key, e := range c.storage {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		c.remove(key, e)
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Clear delete all cache keys
+func (c *cache) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.policy != nil {
+		for key := range c.storage {
+			c.policy.Remove(key)
+		}
+	}
+	c.storage = make(map[string]entry)
+	c.bytes = 0
+}
+
+// EvictionStats returns the number of entries evicted and expired so far
+func (c *cache) EvictionStats() EvictionStats {
+	return EvictionStats{
+		Evicted: atomic.LoadUint64(&c.evicted),
+		Expired: atomic.LoadUint64(&c.expired),
+	}
+}
+
+// evict removes the victims of the policy until there is room for key with size
+// it reports whether the policy dropped key itself, it must be called with the write lock held
+func (c *cache) evict(key string, size int64) (dropped bool) {
+	if c.policy == nil {
+		return false
+	}
+	for (c.maxEntries > 0 && len(c.storage) >= c.maxEntries) || (c.maxBytes > 0 && c.bytes+size > c.maxBytes) {
+		victim, ok := c.policy.Victim()
+		if !ok {
+			return dropped
+		}
+		e, found := c.storage[victim]
+		if !found {
+			// key being overwritten or the policy is out of sync, forget the unknown key
+			c.policy.Remove(victim)
+			dropped = dropped || victim == key
+			continue
+		}
+		c.remove(victim, e)
+		atomic.AddUint64(&c.evicted, 1)
+	}
+	return dropped
+}
+
+// remove deletes key from storage and from the policy, it must be called with the write lock held
+func (c *cache) remove(key string, e entry) {
+	delete(c.storage, key)
+	c.bytes -= entrySize(key, e)
+	if c.policy != nil {
+		c.policy.Remove(key)
+	}
+}
+
+func entrySize(key string, e entry) int64 {
+	return int64(len(key) + len(e.value))
+}
+
+// Close stops the background janitor
+func (c *cache) Close() error {
+	c.stopOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+	return nil
+}
+
+func (c *cache) janitor() {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired removes all the expired keys
+func (c *cache) deleteExpired() {
+	now := c.clock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, e := range c.storage {
+		if e.expired(now) {
+			c.remove(key, e)
+			atomic.AddUint64(&c.expired, 1)
+		}
+	}
+}
+
+func (c *cache) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
 }
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,11 +14,11 @@ func TestGet(t *testing.T) {
 	t.Run("cache_hit", func(t *testing.T) {
 		t.Parallel()
 		c := &cache{
-			storage: make(map[string]string),
+			storage: make(map[string]entry),
 		}
 		cacheKey := "test_cache_hit"
 		cacheValue := "hit"
-		c.storage[cacheKey] = cacheValue
+		c.storage[cacheKey] = entry{value: cacheValue}
 		v, ok := c.Get(cacheKey)
 		require.True(t, ok)
 		require.Equal(t, cacheValue, v)
@@ -25,7 +26,7 @@ func TestGet(t *testing.T) {
 	t.Run("cache_miss", func(t *testing.T) {
 		t.Parallel()
 		c := &cache{
-			storage: make(map[string]string),
+			storage: make(map[string]entry),
 		}
 		cacheKey := "test_cache_miss"
 		v, ok := c.Get(cacheKey)
@@ -39,7 +40,7 @@ func TestSet(t *testing.T) {
 	t.Run("new", func(t *testing.T) {
 		t.Parallel()
 		c := &cache{
-			storage: make(map[string]string),
+			storage: make(map[string]entry),
 		}
 		cacheKey := "test_cache_set_new"
 		cacheValue := "new"
@@ -51,11 +52,11 @@ func TestSet(t *testing.T) {
 	t.Run("overwrite", func(t *testing.T) {
 		t.Parallel()
 		c := &cache{
-			storage: make(map[string]string),
+			storage: make(map[string]entry),
 		}
 		cacheKey := "test_cache_set_overwrite"
 		cacheValue := "overwrite"
-		c.storage[cacheKey] = "new"
+		c.storage[cacheKey] = entry{value: "new"}
 		c.Set(cacheKey, cacheValue)
 		v, ok := c.Get(cacheKey)
 		require.True(t, ok)
@@ -63,6 +64,139 @@ func TestSet(t *testing.T) {
 	})
 }
 
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxEntries(10)).(*cache)
+		c.Set("key", "value")
+		require.True(t, c.Delete("key"))
+		require.False(t, c.Delete("key"))
+		_, ok := c.Get("key")
+		require.False(t, ok)
+		require.Zero(t, c.bytes)
+		_, ok = c.policy.Victim()
+		require.False(t, ok)
+	})
+	t.Run("delete_prefix", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher().(*cache)
+		c.Set("character_info_1", "1")
+		c.Set("character_info_2", "2")
+		c.Set("character_detail_1", "1")
+		require.Equal(t, 2, c.DeletePrefix("character_info_"))
+		require.Equal(t, 0, c.DeletePrefix("character_info_"))
+		require.Len(t, c.storage, 1)
+		_, ok := c.Get("character_detail_1")
+		require.True(t, ok)
+	})
+	t.Run("expired_not_counted", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacher().(*cache)
+		c.now = func() time.Time { return now }
+		c.SetWithTTL("key_1", "1", time.Second)
+		c.SetWithTTL("key_2", "2", time.Hour)
+		now = now.Add(time.Minute)
+		require.Equal(t, 1, c.DeletePrefix("key_"))
+		require.Empty(t, c.storage)
+	})
+	t.Run("clear", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxEntries(10)).(*cache)
+		c.Set("a", "1")
+		c.Set("b", "2")
+		c.Clear()
+		require.Empty(t, c.storage)
+		require.Zero(t, c.bytes)
+		_, ok := c.policy.Victim()
+		require.False(t, ok)
+		c.Set("a", "1")
+		v, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, "1", v)
+	})
+}
+
+func TestTTL(t *testing.T) {
+	t.Parallel()
+	newCache := func(opts ...Option) (*cache, *time.Time) {
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacher(opts...).(*cache)
+		c.now = func() time.Time { return now }
+		return c, &now
+	}
+	t.Run("set_with_ttl", func(t *testing.T) {
+		t.Parallel()
+		c, now := newCache()
+		c.SetWithTTL("key", "value", time.Minute)
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		*now = now.Add(time.Minute)
+		_, ok = c.Get("key")
+		require.False(t, ok)
+		// expired key is removed lazily
+		require.NotContains(t, c.storage, "key")
+	})
+	t.Run("no_ttl", func(t *testing.T) {
+		t.Parallel()
+		c, now := newCache()
+		c.SetWithTTL("key", "value", 0)
+		*now = now.Add(365 * 24 * time.Hour)
+		_, ok := c.Get("key")
+		require.True(t, ok)
+	})
+	t.Run("default_ttl", func(t *testing.T) {
+		t.Parallel()
+		c, now := newCache(WithDefaultTTL(time.Hour))
+		c.Set("key", "value")
+		*now = now.Add(59 * time.Minute)
+		_, ok := c.Get("key")
+		require.True(t, ok)
+		*now = now.Add(time.Minute)
+		_, ok = c.Get("key")
+		require.False(t, ok)
+	})
+	t.Run("overwrite_resets_ttl", func(t *testing.T) {
+		t.Parallel()
+		c, now := newCache()
+		c.SetWithTTL("key", "old", time.Minute)
+		*now = now.Add(30 * time.Second)
+		c.SetWithTTL("key", "new", time.Minute)
+		*now = now.Add(45 * time.Second)
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "new", v)
+	})
+	t.Run("delete_expired", func(t *testing.T) {
+		t.Parallel()
+		c, now := newCache()
+		c.SetWithTTL("expired", "value", time.Second)
+		c.SetWithTTL("alive", "value", time.Hour)
+		c.SetWithTTL("forever", "value", 0)
+		*now = now.Add(time.Minute)
+		c.deleteExpired()
+		require.Len(t, c.storage, 2)
+		require.Contains(t, c.storage, "alive")
+		require.Contains(t, c.storage, "forever")
+	})
+	t.Run("janitor", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithCleanupInterval(time.Millisecond)).(*cache)
+		defer c.Close()
+		c.SetWithTTL("key", "value", time.Millisecond)
+		require.Eventually(t, func() bool {
+			c.lock.RLock()
+			defer c.lock.RUnlock()
+			return len(c.storage) == 0
+		}, time.Second, time.Millisecond)
+		require.NoError(t, c.Close())
+		// closing twice is fine
+		require.NoError(t, c.Close())
+	})
+}
+
 func TestStress(t *testing.T) {
 	t.Parallel()
 	// Stress Test cache used for race detection
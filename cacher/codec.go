@@ -0,0 +1,156 @@
+package cacher
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	Codec_JSON   = "json"
+	Codec_Gob    = "gob"
+	Codec_Binary = "binary"
+)
+
+// ErrCodecUnsupported is returned by a codec which can't encode or decode the type of a value
+var ErrCodecUnsupported = errors.New("value unsupported by the codec")
+
+// Codec encodes the values stored in a cache
+type Codec interface {
+	// Name identifies the codec in the stored entries
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v, a pointer
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec encodes any value, it is the codec of the entries encoded by EncodeEntry
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec encodes any value with encoding/gob, it is faster to decode than JSON for big structs
+	GobCodec Codec = gobCodec{}
+	// BinaryCodec stores []byte as is, encodes []int as varints and the other values with their
+	// encoding.BinaryMarshaler, the other types are unsupported
+	BinaryCodec Codec = binaryCodec{}
+)
+
+// CodecByName returns the codec of name, e.g. the codec of a stored entry
+func CodecByName(name string) (Codec, bool) {
+	switch name {
+	case Codec_JSON:
+		return JSONCodec, true
+	case Codec_Gob:
+		return GobCodec, true
+	case Codec_Binary:
+		return BinaryCodec, true
+	default:
+		return nil, false
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return Codec_JSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string {
+	return Codec_Gob
+}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type binaryCodec struct{}
+
+func (binaryCodec) Name() string {
+	return Codec_Binary
+}
+
+func (binaryCodec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case *[]byte:
+		return *v, nil
+	case []int:
+		return marshalInts(v), nil
+	case *[]int:
+		return marshalInts(*v), nil
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	default:
+		return nil, fmt.Errorf("%w: binary %T", ErrCodecUnsupported, v)
+	}
+}
+
+func (binaryCodec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *[]byte:
+		*v = append([]byte(nil), data...)
+		return nil
+	case *[]int:
+		return unmarshalInts(data, v)
+	case encoding.BinaryUnmarshaler:
+		return v.UnmarshalBinary(data)
+	default:
+		return fmt.Errorf("%w: binary %T", ErrCodecUnsupported, v)
+	}
+}
+
+// marshalInts encodes the count then the values as varints
+func marshalInts(list []int) []byte {
+	b := make([]byte, 0, binary.MaxVarintLen64*(len(list)+1))
+	buf := make([]byte, binary.MaxVarintLen64)
+	b = append(b, buf[:binary.PutUvarint(buf, uint64(len(list)))]...)
+	for _, i := range list {
+		b = append(b, buf[:binary.PutVarint(buf, int64(i))]...)
+	}
+	return b
+}
+
+func unmarshalInts(data []byte, list *[]int) error {
+	count, n := binary.Uvarint(data)
+	// every value takes at least a byte, a corrupted count doesn't allocate more than the data
+	if n <= 0 || count > uint64(len(data)-n) {
+		return errors.New("binary codec: invalid int list")
+	}
+	data = data[n:]
+	values := make([]int, count)
+	for i := range values {
+		v, n := binary.Varint(data)
+		if n <= 0 {
+			return errors.New("binary codec: invalid int list")
+		}
+		values[i] = int(v)
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return errors.New("binary codec: invalid int list")
+	}
+	*list = values
+	return nil
+}
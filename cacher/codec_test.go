@@ -0,0 +1,73 @@
+package cacher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type codecValue struct {
+	ID   int
+	Name string
+}
+
+func TestCodec(t *testing.T) {
+	t.Parallel()
+	t.Run("round_trip", func(t *testing.T) {
+		t.Parallel()
+		for _, codec := range []Codec{JSONCodec, GobCodec, BinaryCodec} {
+			found, ok := CodecByName(codec.Name())
+			require.True(t, ok)
+			require.Equal(t, codec, found)
+
+			list := []int{1011334, -1, 0, 1 << 40}
+			b, err := codec.Marshal(&list)
+			require.NoError(t, err, codec.Name())
+			var decoded []int
+			require.NoError(t, codec.Unmarshal(b, &decoded), codec.Name())
+			require.Equal(t, list, decoded, codec.Name())
+		}
+		for _, codec := range []Codec{JSONCodec, GobCodec} {
+			b, err := codec.Marshal(&codecValue{ID: 1, Name: "test"})
+			require.NoError(t, err, codec.Name())
+			var decoded codecValue
+			require.NoError(t, codec.Unmarshal(b, &decoded), codec.Name())
+			require.Equal(t, codecValue{ID: 1, Name: "test"}, decoded, codec.Name())
+		}
+		_, ok := CodecByName("unknown")
+		require.False(t, ok)
+	})
+	t.Run("binary", func(t *testing.T) {
+		t.Parallel()
+		raw := []byte("\x00binary\xff")
+		b, err := BinaryCodec.Marshal(raw)
+		require.NoError(t, err)
+		require.Equal(t, raw, b)
+		var decoded []byte
+		require.NoError(t, BinaryCodec.Unmarshal(b, &decoded))
+		require.Equal(t, raw, decoded)
+
+		// the ids take 3 bytes instead of 8 in json
+		b, err = BinaryCodec.Marshal([]int{1011334, 1011335})
+		require.NoError(t, err)
+		require.Len(t, b, 7)
+
+		_, err = BinaryCodec.Marshal(&codecValue{})
+		require.ErrorIs(t, err, ErrCodecUnsupported)
+		require.ErrorIs(t, BinaryCodec.Unmarshal(b, &codecValue{}), ErrCodecUnsupported)
+	})
+	t.Run("corrupted_ints", func(t *testing.T) {
+		t.Parallel()
+		b, err := BinaryCodec.Marshal([]int{1, 2, 3})
+		require.NoError(t, err)
+		for name, data := range map[string][]byte{
+			"empty":     {},
+			"count":     {0xff, 0xff, 0xff, 0xff, 0x0f},
+			"truncated": b[:len(b)-1],
+			"trailing":  append(append([]byte{}, b...), 1),
+		} {
+			var list []int
+			require.Error(t, BinaryCodec.Unmarshal(data, &list), name)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package cacher
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// entryMagic starts the encoded entries, it can't start a JSON value
+//
+// the format is: magic, stored time in unix nanoseconds (int64), flags (byte), codec name length (byte), codec name, value
+const entryMagic = "\x00XPE"
+
+const entryMissing = 1
+
+// Entry is a value cached with the time it was stored, so that readers can tell how fresh it is
+type Entry struct {
+	StoredAt time.Time
+	// Codec is the name of the codec which encoded Value
+	Codec string
+	Value []byte
+	// Missing is set instead of Value when the value was looked up and doesn't exist
+	Missing bool
+}
+
+// jsonEntry is the JSON envelope of the entries cached by previous versions
+type jsonEntry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Missing  bool            `json:"missing,omitempty"`
+}
+
+// Encode encodes the entry, the value may be any bytes
+func (e Entry) Encode() string {
+	var b strings.Builder
+	b.Grow(len(entryMagic) + 10 + len(e.Codec) + len(e.Value))
+	b.WriteString(entryMagic)
+	header := make([]byte, 10)
+	binary.BigEndian.PutUint64(header, uint64(e.StoredAt.UnixNano()))
+	if e.Missing {
+		header[8] = entryMissing
+	}
+	header[9] = byte(len(e.Codec))
+	b.Write(header)
+	b.WriteString(e.Codec[:int(header[9])])
+	b.Write(e.Value)
+	return b.String()
+}
+
+// EncodeEntry encodes the JSON value stored at storedAt as an entry
+func EncodeEntry(value []byte, storedAt time.Time) (string, error) {
+	if !json.Valid(value) {
+		return "", errors.New("invalid json entry value")
+	}
+	return Entry{StoredAt: storedAt, Codec: Codec_JSON, Value: value}.Encode(), nil
+}
+
+// EncodeMissingEntry encodes the lookup at storedAt of a value which doesn't exist
+func EncodeMissingEntry(storedAt time.Time) (string, error) {
+	return Entry{StoredAt: storedAt, Missing: true}.Encode(), nil
+}
+
+// DecodeEntry decodes an entry, ok is false if s wasn't encoded as an entry
+func DecodeEntry(s string) (entry Entry, ok bool) {
+	if !strings.HasPrefix(s, entryMagic) {
+		return decodeJSONEntry(s)
+	}
+	s = s[len(entryMagic):]
+	if len(s) < 10 || len(s) < 10+int(s[9]) {
+		return entry, false
+	}
+	entry.StoredAt = time.Unix(0, int64(binary.BigEndian.Uint64([]byte(s[:8]))))
+	entry.Missing = s[8]&entryMissing != 0
+	entry.Codec = s[10 : 10+int(s[9])]
+	if value := s[10+int(s[9]):]; !entry.Missing {
+		entry.Value = []byte(value)
+	} else if value != "" {
+		return entry, false
+	}
+	return entry, true
+}
+
+func decodeJSONEntry(s string) (entry Entry, ok bool) {
+	var e jsonEntry
+	if err := json.Unmarshal([]byte(s), &e); err != nil {
+		return entry, false
+	}
+	entry = Entry{StoredAt: e.StoredAt, Value: e.Value, Missing: e.Missing}
+	if e.Value != nil {
+		entry.Codec = Codec_JSON
+	}
+	return entry, !entry.StoredAt.IsZero() && (entry.Value != nil) != entry.Missing
+}
@@ -0,0 +1,65 @@
+package cacher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntry(t *testing.T) {
+	t.Parallel()
+	storedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := EncodeEntry([]byte(`[1,2,3]`), storedAt)
+	require.NoError(t, err)
+	entry, ok := DecodeEntry(s)
+	require.True(t, ok)
+	require.True(t, storedAt.Equal(entry.StoredAt))
+	require.JSONEq(t, `[1,2,3]`, string(entry.Value))
+	require.False(t, entry.Missing)
+
+	s, err = EncodeMissingEntry(storedAt)
+	require.NoError(t, err)
+	entry, ok = DecodeEntry(s)
+	require.True(t, ok)
+	require.True(t, entry.Missing)
+	require.Nil(t, entry.Value)
+
+	_, err = EncodeEntry([]byte(`invalid`), storedAt)
+	require.Error(t, err)
+	for _, raw := range []string{`[1,2,3]`, `{"id":1}`, `{"value":1}`, `{"storedAt":"2021-01-01T00:00:00Z"}`,
+		`{"storedAt":"2021-01-01T00:00:00Z","value":1,"missing":true}`, `invalid`} {
+		_, ok := DecodeEntry(raw)
+		require.False(t, ok, raw)
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		t.Parallel()
+		entry := Entry{StoredAt: storedAt, Codec: Codec_Binary, Value: []byte("\x00value\xff")}
+		decoded, ok := DecodeEntry(entry.Encode())
+		require.True(t, ok)
+		require.True(t, storedAt.Equal(decoded.StoredAt))
+		require.Equal(t, Codec_Binary, decoded.Codec)
+		require.Equal(t, entry.Value, decoded.Value)
+
+		encoded := entry.Encode()
+		_, ok = DecodeEntry(encoded[:len(entryMagic)+8])
+		require.False(t, ok)
+		missing, err := EncodeMissingEntry(storedAt)
+		require.NoError(t, err)
+		_, ok = DecodeEntry(missing + "value")
+		require.False(t, ok)
+	})
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		// cached by previous versions
+		entry, ok := DecodeEntry(`{"storedAt":"2021-01-01T00:00:00Z","value":[1,2,3]}`)
+		require.True(t, ok)
+		require.True(t, storedAt.Equal(entry.StoredAt))
+		require.Equal(t, Codec_JSON, entry.Codec)
+		require.JSONEq(t, `[1,2,3]`, string(entry.Value))
+		entry, ok = DecodeEntry(`{"storedAt":"2021-01-01T00:00:00Z","missing":true}`)
+		require.True(t, ok)
+		require.True(t, entry.Missing)
+	})
+}
@@ -0,0 +1,215 @@
+package cacher
+
+import (
+	"container/heap"
+	"container/list"
+	"sync"
+)
+
+// EvictionPolicy chooses the key to evict when the cache is over its bounds
+// the cache notifies the policy of every key it stores, reads and removes
+// implementations must be safe for concurrent use, Access is called by concurrent readers
+type EvictionPolicy interface {
+	// Add is called when a new key is stored
+	Add(key string)
+	// Access is called when a key is read or overwritten, unknown keys are ignored
+	Access(key string)
+	// Remove is called when a key is removed from the cache
+	Remove(key string)
+	// Victim returns the key to evict next, false if the policy has no key
+	Victim() (string, bool)
+}
+
+// EvictionStats counts the entries removed from the cache
+type EvictionStats struct {
+	// Evicted is the number of entries evicted to keep the cache in bounds
+	Evicted uint64
+	// Expired is the number of expired entries removed
+	Expired uint64
+}
+
+// WithMaxEntries bounds the number of keys in the cache, 0 is unbounded
+func WithMaxEntries(n int) Option {
+	return func(c *cache) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes bounds the approximate size (keys and values length) of the cache, 0 is unbounded
+// a value bigger than the bound is never stored
+func WithMaxBytes(n int64) Option {
+	return func(c *cache) {
+		c.maxBytes = n
+	}
+}
+
+// WithEvictionPolicy sets the policy choosing the keys to evict, a bounded cache uses LRU by default
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *cache) {
+		c.policy = policy
+	}
+}
+
+// WithEvictionPolicyFunc sets the function creating the policy of the cache
+// a sharded cache needs it instead of WithEvictionPolicy, every shard has its own policy
+func WithEvictionPolicyFunc(newPolicy func() EvictionPolicy) Option {
+	return func(c *cache) {
+		c.newPolicy = newPolicy
+	}
+}
+
+// lruPolicy evicts the least recently used key
+type lruPolicy struct {
+	lock  sync.Mutex
+	order *list.List // front is the most recently used
+	items map[string]*list.Element
+}
+
+// NewLRUPolicy creates a least recently used eviction policy
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (p *lruPolicy) Add(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) Access(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if e, ok := p.items[key]; ok {
+		p.order.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *lruPolicy) Victim() (string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	e := p.order.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
+
+// lfuPolicy evicts the least frequently used key, ties are broken by the least recent use
+type lfuPolicy struct {
+	lock  sync.Mutex
+	tick  uint64
+	heap  lfuHeap
+	items map[string]*lfuItem
+}
+
+type lfuItem struct {
+	key   string
+	count uint64
+	// tick is the logical time of the last use
+	tick  uint64
+	index int
+}
+
+// NewLFUPolicy creates a least frequently used eviction policy
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{
+		items: make(map[string]*lfuItem),
+	}
+}
+
+func (p *lfuPolicy) Add(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.items[key]; ok {
+		p.touch(key)
+		return
+	}
+	p.tick++
+	item := &lfuItem{key: key, count: 1, tick: p.tick}
+	p.items[key] = item
+	heap.Push(&p.heap, item)
+}
+
+func (p *lfuPolicy) Access(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.touch(key)
+}
+
+func (p *lfuPolicy) touch(key string) {
+	item, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.tick++
+	item.count++
+	item.tick = p.tick
+	heap.Fix(&p.heap, item.index)
+}
+
+func (p *lfuPolicy) Remove(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if item, ok := p.items[key]; ok {
+		heap.Remove(&p.heap, item.index)
+		delete(p.items, key)
+	}
+}
+
+func (p *lfuPolicy) Victim() (string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.heap) == 0 {
+		return "", false
+	}
+	return p.heap[0].key, true
+}
+
+// lfuHeap is a min heap of the items by use count then last use
+type lfuHeap []*lfuItem
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	item := x.(*lfuItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
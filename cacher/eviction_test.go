@@ -0,0 +1,161 @@
+package cacher
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	t.Parallel()
+	p := NewLRUPolicy()
+	_, ok := p.Victim()
+	require.False(t, ok)
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	victim, ok := p.Victim()
+	require.True(t, ok)
+	require.Equal(t, "a", victim)
+	p.Access("a")
+	victim, _ = p.Victim()
+	require.Equal(t, "b", victim)
+	p.Remove("b")
+	victim, _ = p.Victim()
+	require.Equal(t, "c", victim)
+	// unknown keys are ignored
+	p.Access("unknown")
+	p.Remove("unknown")
+	victim, _ = p.Victim()
+	require.Equal(t, "c", victim)
+}
+
+func TestLFUPolicy(t *testing.T) {
+	t.Parallel()
+	p := NewLFUPolicy()
+	_, ok := p.Victim()
+	require.False(t, ok)
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	// same count, the least recently used goes first
+	victim, ok := p.Victim()
+	require.True(t, ok)
+	require.Equal(t, "a", victim)
+	p.Access("a")
+	p.Access("a")
+	p.Access("b")
+	victim, _ = p.Victim()
+	require.Equal(t, "c", victim)
+	p.Remove("c")
+	victim, _ = p.Victim()
+	require.Equal(t, "b", victim)
+	p.Access("unknown")
+	p.Remove("unknown")
+	victim, _ = p.Victim()
+	require.Equal(t, "b", victim)
+}
+
+func TestBoundedCache(t *testing.T) {
+	t.Parallel()
+	t.Run("max_entries_lru", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxEntries(2)).(*cache)
+		c.Set("a", "1")
+		c.Set("b", "2")
+		c.Get("a")
+		c.Set("c", "3")
+		require.Len(t, c.storage, 2)
+		_, ok := c.Get("b")
+		require.False(t, ok)
+		_, ok = c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, EvictionStats{Evicted: 1}, c.EvictionStats())
+	})
+	t.Run("max_entries_lfu", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxEntries(2), WithEvictionPolicy(NewLFUPolicy())).(*cache)
+		c.Set("a", "1")
+		c.Set("b", "2")
+		c.Get("a")
+		c.Get("a")
+		c.Get("b")
+		c.Set("c", "3")
+		// b is more recent than a but used less
+		_, ok := c.Get("b")
+		require.False(t, ok)
+		_, ok = c.Get("a")
+		require.True(t, ok)
+		_, ok = c.Get("c")
+		require.True(t, ok)
+	})
+	t.Run("max_bytes", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxBytes(10)).(*cache)
+		c.Set("a", "1234")
+		c.Set("b", "1234")
+		require.EqualValues(t, 10, c.bytes)
+		c.Set("c", "1")
+		require.EqualValues(t, 7, c.bytes)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		// overwriting a key replaces its size
+		c.Set("b", "1")
+		require.EqualValues(t, 4, c.bytes)
+	})
+	t.Run("value_too_big", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher(WithMaxBytes(10)).(*cache)
+		c.Set("a", "1")
+		c.Set("b", "1")
+		c.Set("b", "12345678901")
+		_, ok := c.Get("b")
+		require.False(t, ok)
+		_, ok = c.Get("a")
+		require.True(t, ok)
+		require.EqualValues(t, 2, c.bytes)
+		require.EqualValues(t, 1, c.EvictionStats().Evicted)
+	})
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacher(WithMaxEntries(10)).(*cache)
+		c.now = func() time.Time { return now }
+		c.SetWithTTL("a", "1", time.Second)
+		c.SetWithTTL("b", "1", time.Second)
+		now = now.Add(time.Minute)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		c.deleteExpired()
+		require.Empty(t, c.storage)
+		require.Zero(t, c.bytes)
+		require.Equal(t, EvictionStats{Expired: 2}, c.EvictionStats())
+		_, ok = c.policy.Victim()
+		require.False(t, ok)
+	})
+	t.Run("stress", func(t *testing.T) {
+		t.Parallel()
+		for _, policy := range []EvictionPolicy{NewLRUPolicy(), NewLFUPolicy()} {
+			c := NewCacher(WithMaxEntries(5), WithEvictionPolicy(policy)).(*cache)
+			n := 1000
+			var wg sync.WaitGroup
+			wg.Add(2 * n)
+			for i := 0; i < n; i++ {
+				cacheKey := "test_stress_" + strconv.Itoa(i%13)
+				go func() {
+					defer wg.Done()
+					c.Set(cacheKey, cacheKey)
+				}()
+				go func() {
+					defer wg.Done()
+					c.Get(cacheKey)
+				}()
+			}
+			wg.Wait()
+			require.LessOrEqual(t, len(c.storage), 5)
+		}
+	})
+}
@@ -0,0 +1,606 @@
+package cacher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	Redis_Default_Pool_Size      = 10
+	Redis_Default_Timeout        = time.Second
+	Redis_Default_Retry_Interval = 10 * time.Second
+	// redisScanCount is the number of keys scanned per call when listing or deleting by prefix
+	redisScanCount = 500
+)
+
+// RedisOption configures the cache created by NewRedisCacher
+type RedisOption func(*redisCache)
+
+// WithRedisDefaultTTL sets the ttl of the keys set by Set, by default keys never expire
+func WithRedisDefaultTTL(ttl time.Duration) RedisOption {
+	return func(c *redisCache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithRedisPoolSize sets the maximum number of connections to the redis server
+func WithRedisPoolSize(n int) RedisOption {
+	return func(c *redisCache) {
+		if n > 0 {
+			c.poolSize = n
+		}
+	}
+}
+
+// WithRedisTimeout sets the dial, read and write timeout of a command
+func WithRedisTimeout(timeout time.Duration) RedisOption {
+	return func(c *redisCache) {
+		c.timeout = timeout
+	}
+}
+
+// WithRedisPassword authenticates the connections with password
+func WithRedisPassword(password string) RedisOption {
+	return func(c *redisCache) {
+		c.password = password
+	}
+}
+
+// WithRedisKeyPrefix namespaces the keys of the cache with prefix, e.g. "marvel:"
+// the cache doesn't read, list nor delete the keys out of its namespace, so that it can share a redis database
+func WithRedisKeyPrefix(prefix string) RedisOption {
+	return func(c *redisCache) {
+		c.keyPrefix = prefix
+	}
+}
+
+// WithRedisFallback serves the cache from fallback while the redis server is unreachable
+func WithRedisFallback(fallback Cacher) RedisOption {
+	return func(c *redisCache) {
+		c.fallback = fallback
+	}
+}
+
+// WithRedisRetryInterval sets how long the cache stays on the fallback before trying the redis server again
+func WithRedisRetryInterval(interval time.Duration) RedisOption {
+	return func(c *redisCache) {
+		c.retryInterval = interval
+	}
+}
+
+// redisError is an error reply of the redis server, the connection is still usable after it
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is a connection speaking the RESP protocol
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+type redisCache struct {
+	// the counters are accessed atomically, they are kept first for 64-bit alignment
+	hits   uint64
+	misses uint64
+	sets   uint64
+
+	addr          string
+	password      string
+	keyPrefix     string
+	defaultTTL    time.Duration
+	poolSize      int
+	timeout       time.Duration
+	retryInterval time.Duration
+	fallback      Cacher
+
+	// sem bounds the number of open connections, idle keeps the connections for reuse
+	sem  chan struct{}
+	idle chan *redisConn
+
+	lock sync.Mutex
+	// downUntil is set when the server is unreachable, the fallback is used until then
+	downUntil time.Time
+	// recovering is set once the server is tried again after an outage
+	recovering bool
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewRedisCacher create a cache stored in the redis server at addr
+// without fallback, the cache misses and drops writes while the server is unreachable
+func NewRedisCacher(addr string, opts ...RedisOption) Cacher {
+	c := &redisCache{
+		addr:          addr,
+		poolSize:      Redis_Default_Pool_Size,
+		timeout:       Redis_Default_Timeout,
+		retryInterval: Redis_Default_Retry_Interval,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.sem = make(chan struct{}, c.poolSize)
+	c.idle = make(chan *redisConn, c.poolSize)
+	return c
+}
+
+// Get get cache from key
+func (c *redisCache) Get(key string) (string, bool) {
+	v, ok := c.lookup(key)
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+	return v, ok
+}
+
+func (c *redisCache) lookup(key string) (string, bool) {
+	if c.down() {
+		return c.fallbackGet(key)
+	}
+	reply, err := c.do("GET", c.key(key))
+	if err != nil {
+		if c.failed(err) {
+			return c.fallbackGet(key)
+		}
+		return "", false
+	}
+	v, ok := reply.(string)
+	return v, ok
+}
+
+// Set set cache key with value
+func (c *redisCache) Set(key string, value string) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL set cache key with value expiring after ttl
+func (c *redisCache) SetWithTTL(key string, value string, ttl time.Duration) {
+	atomic.AddUint64(&c.sets, 1)
+	if c.down() {
+		c.fallbackSet(key, value, ttl)
+		return
+	}
+	args := []string{"SET", c.key(key), value}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	if _, err := c.do(args...); err != nil && c.failed(err) {
+		c.fallbackSet(key, value, ttl)
+	}
+}
+
+// Delete delete cache key
+func (c *redisCache) Delete(key string) bool {
+	if c.down() {
+		return c.fallback != nil && c.fallback.Delete(key)
+	}
+	reply, err := c.do("DEL", c.key(key))
+	if err != nil {
+		if c.failed(err) && c.fallback != nil {
+			return c.fallback.Delete(key)
+		}
+		return false
+	}
+	n, _ := reply.(int64)
+	return n > 0
+}
+
+// DeletePrefix delete all cache keys starting with prefix
+func (c *redisCache) DeletePrefix(prefix string) int {
+	if c.down() {
+		if c.fallback == nil {
+			return 0
+		}
+		return c.fallback.DeletePrefix(prefix)
+	}
+	n, err := c.deletePrefix(prefix)
+	if err != nil && c.failed(err) && c.fallback != nil {
+		return n + c.fallback.DeletePrefix(prefix)
+	}
+	return n
+}
+
+// Clear delete all cache keys, it deletes every key of the redis database unless the keys are namespaced
+func (c *redisCache) Clear() {
+	c.DeletePrefix("")
+	if c.fallback != nil {
+		c.fallback.Clear()
+	}
+}
+
+// Stats returns the reads and writes of the cache, the size of the redis database isn't reported
+func (c *redisCache) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Sets:   atomic.LoadUint64(&c.sets),
+	}
+}
+
+// Keys returns up to limit keys starting with prefix in order, and the number of such keys
+// the keys are scanned in the namespace of the cache, they are listed from the fallback while the server is unreachable
+func (c *redisCache) Keys(prefix string, limit int) ([]string, int) {
+	if c.down() {
+		return c.fallbackKeys(prefix, limit)
+	}
+	var keys []string
+	err := c.scan(prefix, func(batch []string) error {
+		for _, key := range batch {
+			keys = append(keys, strings.TrimPrefix(key, c.keyPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		if c.failed(err) {
+			return c.fallbackKeys(prefix, limit)
+		}
+		return nil, 0
+	}
+	sort.Strings(keys)
+	// a key may be returned more than once by a scan
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	keys = unique
+	total := len(keys)
+	if limit > 0 && total > limit {
+		keys = keys[:limit]
+	}
+	return keys, total
+}
+
+// Inspect returns the value and the expiry of a key
+func (c *redisCache) Inspect(key string) (KeyInfo, bool) {
+	if c.down() {
+		return c.fallbackInspect(key)
+	}
+	reply, err := c.do("GET", c.key(key))
+	if err != nil {
+		if c.failed(err) {
+			return c.fallbackInspect(key)
+		}
+		return KeyInfo{}, false
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return KeyInfo{}, false
+	}
+	reply, err = c.do("PTTL", c.key(key))
+	if err != nil {
+		if c.failed(err) {
+			return c.fallbackInspect(key)
+		}
+		return KeyInfo{}, false
+	}
+	info := KeyInfo{Key: key, Value: value, Size: int64(len(key) + len(value))}
+	// -2 when the key expired meanwhile, -1 when it never expires
+	switch ms, _ := reply.(int64); {
+	case ms == -2:
+		return KeyInfo{}, false
+	case ms >= 0:
+		info.ExpiresAt = c.now().Add(time.Duration(ms) * time.Millisecond)
+	}
+	return info, true
+}
+
+// Close closes the idle connections and the fallback
+func (c *redisCache) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.conn.Close()
+			<-c.sem
+		default:
+			if closer, ok := c.fallback.(io.Closer); ok {
+				return closer.Close()
+			}
+			return nil
+		}
+	}
+}
+
+// deletePrefix scans the keys matching prefix and deletes them by batch
+func (c *redisCache) deletePrefix(prefix string) (int, error) {
+	deleted := 0
+	err := c.scan(prefix, func(keys []string) error {
+		reply, err := c.do(append([]string{"DEL"}, keys...)...)
+		if err != nil {
+			return err
+		}
+		n, _ := reply.(int64)
+		deleted += int(n)
+		return nil
+	})
+	return deleted, err
+}
+
+// scan calls fn with the batches of the keys starting with prefix in the namespace of the cache
+// the keys are passed as stored, with the namespace prefix
+func (c *redisCache) scan(prefix string, fn func(keys []string) error) error {
+	pattern := escapeGlob(c.key(prefix)) + "*"
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(redisScanCount))
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return fmt.Errorf("redis: unexpected scan reply %v", reply)
+		}
+		cursor, _ = parts[0].(string)
+		items, _ := parts[1].([]interface{})
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if key, ok := item.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// key is key in the namespace of the cache
+func (c *redisCache) key(key string) string {
+	return c.keyPrefix + key
+}
+
+// down tells whether the server is known unreachable and the fallback must be used
+func (c *redisCache) down() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.downUntil.IsZero() {
+		return false
+	}
+	if c.now().Before(c.downUntil) {
+		return true
+	}
+	// give the server another try
+	c.downUntil = time.Time{}
+	c.recovering = true
+	return false
+}
+
+// recovered is called when the server replied
+// after an outage, the fallback content is dropped so that it doesn't resurface stale values at the next one
+func (c *redisCache) recovered() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.recovering {
+		return
+	}
+	c.recovering = false
+	if c.fallback != nil {
+		c.fallback.Clear()
+	}
+}
+
+// failed handles the error of a command, it reports whether the server is unreachable
+func (c *redisCache) failed(err error) bool {
+	var replyErr redisError
+	if errors.As(err, &replyErr) {
+		// log error
+		log.Println("redis error", err)
+		return false
+	}
+	// log error
+	log.Println("redis unreachable, use fallback", err)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.downUntil = c.now().Add(c.retryInterval)
+	return true
+}
+
+func (c *redisCache) fallbackGet(key string) (string, bool) {
+	if c.fallback == nil {
+		return "", false
+	}
+	return c.fallback.Get(key)
+}
+
+func (c *redisCache) fallbackKeys(prefix string, limit int) ([]string, int) {
+	if inspector, ok := c.fallback.(Inspector); ok {
+		return inspector.Keys(prefix, limit)
+	}
+	return nil, 0
+}
+
+func (c *redisCache) fallbackInspect(key string) (KeyInfo, bool) {
+	if inspector, ok := c.fallback.(Inspector); ok {
+		return inspector.Inspect(key)
+	}
+	return KeyInfo{}, false
+}
+
+func (c *redisCache) fallbackSet(key, value string, ttl time.Duration) {
+	if c.fallback != nil {
+		c.fallback.SetWithTTL(key, value, ttl)
+	}
+}
+
+// do sends a command and reads its reply
+func (c *redisCache) do(args ...string) (interface{}, error) {
+	conn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.timeout, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// the connection state is unknown after a network error
+		conn.conn.Close()
+		<-c.sem
+		return nil, err
+	}
+	c.put(conn)
+	c.recovered()
+	return reply, err
+}
+
+// get takes an idle connection or dials a new one
+func (c *redisCache) get() (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	case c.sem <- struct{}{}:
+	}
+	conn, err := c.dial()
+	if err != nil {
+		<-c.sem
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *redisCache) put(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+		<-c.sem
+	}
+}
+
+func (c *redisCache) dial() (*redisConn, error) {
+	netConn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{
+		conn: netConn,
+		r:    bufio.NewReader(netConn),
+		w:    bufio.NewWriter(netConn),
+	}
+	if c.password != "" {
+		if _, err := conn.do(c.timeout, "AUTH", c.password); err != nil {
+			netConn.Close()
+			// the server is as unusable as unreachable with a wrong password
+			return nil, fmt.Errorf("redis auth: %v", err)
+		}
+	}
+	return conn, nil
+}
+
+// do writes the command as an array of bulk strings and reads the reply
+func (conn *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	if timeout > 0 {
+		if err := conn.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(conn.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(conn.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := conn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+// readReply reads a RESP reply: strings, integers, nil or arrays of them
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			var replyErr redisError
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// escapeGlob escapes the glob special characters of a redis MATCH pattern
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,267 @@
+package cacher
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T, password string) *test.RedisServer {
+	server, err := test.NewRedisServer(password)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedisCacher(t *testing.T) {
+	t.Parallel()
+	t.Run("get_set", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		c := NewRedisCacher(server.Addr())
+		defer c.(*redisCache).Close()
+		_, ok := c.Get("key")
+		require.False(t, ok)
+		c.Set("key", "value\r\nwith new line")
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value\r\nwith new line", v)
+		c.Set("empty", "")
+		v, ok = c.Get("empty")
+		require.True(t, ok)
+		require.Empty(t, v)
+	})
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		c := NewRedisCacher(server.Addr(), WithRedisDefaultTTL(20*time.Millisecond))
+		c.Set("default", "value")
+		c.SetWithTTL("forever", "value", 0)
+		_, ok := c.Get("default")
+		require.True(t, ok)
+		require.Eventually(t, func() bool {
+			_, ok := c.Get("default")
+			return !ok
+		}, time.Second, 5*time.Millisecond)
+		_, ok = c.Get("forever")
+		require.True(t, ok)
+	})
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		c := NewRedisCacher(server.Addr())
+		c.Set("character_info_1", "1")
+		c.Set("character_info_2", "2")
+		c.Set("character_info*", "3")
+		c.Set("character_detail_1", "1")
+		require.True(t, c.Delete("character_info_1"))
+		require.False(t, c.Delete("character_info_1"))
+		// the prefix is not a glob pattern
+		require.Equal(t, 1, c.DeletePrefix("character_info*"))
+		require.Equal(t, 1, c.DeletePrefix("character_info_"))
+		_, ok := server.Get("character_detail_1")
+		require.True(t, ok)
+		c.Clear()
+		_, ok = server.Get("character_detail_1")
+		require.False(t, ok)
+	})
+	t.Run("key_prefix", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		shared := NewRedisCacher(server.Addr())
+		shared.Set("other_app", "value")
+		c := NewRedisCacher(server.Addr(), WithRedisKeyPrefix("marvel:"))
+		c.Set("key", "value")
+		v, ok := server.Get("marvel:key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		_, ok = c.Get("other_app")
+		require.False(t, ok)
+		require.False(t, c.Delete("other_app"))
+		require.Zero(t, c.DeletePrefix("other"))
+		// the keys out of the namespace are kept
+		c.Clear()
+		_, ok = server.Get("marvel:key")
+		require.False(t, ok)
+		_, ok = server.Get("other_app")
+		require.True(t, ok)
+	})
+	t.Run("stats_inspect", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewRedisCacher(server.Addr(), WithRedisKeyPrefix("marvel:")).(*redisCache)
+		c.now = func() time.Time { return now }
+		NewRedisCacher(server.Addr()).Set("other_app", "value")
+		c.Set("character_info_2", "2")
+		c.SetWithTTL("character_info_1", "1", time.Hour)
+		c.Set("characters", "[1,2]")
+		c.Get("characters")
+		c.Get("missing")
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Sets: 3}, c.Stats())
+
+		keys, total := c.Keys("", 0)
+		require.Equal(t, []string{"character_info_1", "character_info_2", "characters"}, keys)
+		require.Equal(t, 3, total)
+		keys, total = c.Keys("character_info_", 1)
+		require.Equal(t, []string{"character_info_1"}, keys)
+		require.Equal(t, 2, total)
+
+		info, ok := c.Inspect("character_info_1")
+		require.True(t, ok)
+		require.Equal(t, "1", info.Value)
+		require.Equal(t, int64(len("character_info_1")+1), info.Size)
+		require.WithinDuration(t, now.Add(time.Hour), info.ExpiresAt, time.Second)
+		info, ok = c.Inspect("characters")
+		require.True(t, ok)
+		require.True(t, info.ExpiresAt.IsZero())
+		_, ok = c.Inspect("missing")
+		require.False(t, ok)
+		_, ok = c.Inspect("other_app")
+		require.False(t, ok)
+	})
+	t.Run("password", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "secret")
+		c := NewRedisCacher(server.Addr(), WithRedisPassword("secret"))
+		c.Set("key", "value")
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+
+		fallback := NewCacher()
+		c = NewRedisCacher(server.Addr(), WithRedisPassword("wrong"), WithRedisFallback(fallback))
+		c.Set("key", "other")
+		v, ok = c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "other", v)
+		v, _ = server.Get("key")
+		require.Equal(t, "value", v)
+	})
+	t.Run("pool", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		c := NewRedisCacher(server.Addr(), WithRedisPoolSize(3)).(*redisCache)
+		n := 200
+		var wg sync.WaitGroup
+		wg.Add(2 * n)
+		for i := 0; i < n; i++ {
+			cacheKey := "test_stress_" + strconv.Itoa(i%13)
+			go func() {
+				defer wg.Done()
+				c.Set(cacheKey, cacheKey)
+			}()
+			go func() {
+				defer wg.Done()
+				if v, ok := c.Get(cacheKey); ok {
+					require.Equal(t, cacheKey, v)
+				}
+			}()
+		}
+		wg.Wait()
+		require.LessOrEqual(t, len(c.idle), 3)
+		require.Equal(t, 2*n, server.Commands())
+		require.NoError(t, c.Close())
+		require.Empty(t, c.idle)
+		require.Empty(t, c.sem)
+	})
+	t.Run("redis_error_is_not_outage", func(t *testing.T) {
+		t.Parallel()
+		server := newTestRedis(t, "")
+		c := NewRedisCacher(server.Addr(), WithRedisFallback(NewCacher())).(*redisCache)
+		_, err := c.do("UNKNOWN")
+		require.Error(t, err)
+		require.False(t, c.failed(err))
+		require.False(t, c.down())
+	})
+	t.Run("fallback", func(t *testing.T) {
+		t.Parallel()
+		down := newTestRedis(t, "")
+		down.Close()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		fallback := NewCacher()
+		c := NewRedisCacher(down.Addr(),
+			WithRedisFallback(fallback),
+			WithRedisRetryInterval(time.Minute),
+			WithRedisTimeout(100*time.Millisecond),
+		).(*redisCache)
+		c.now = func() time.Time { return now }
+		c.Set("key", "value")
+		require.True(t, c.down())
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		keys, _ := c.Keys("", 0)
+		require.Equal(t, []string{"key"}, keys)
+		require.Equal(t, 1, c.DeletePrefix("k"))
+
+		// the server is tried again after the retry interval
+		server := newTestRedis(t, "")
+		c.addr = server.Addr()
+		c.Set("key", "value")
+		require.True(t, c.down())
+		now = now.Add(time.Minute)
+		c.Set("other", "value")
+		_, ok = server.Get("other")
+		require.True(t, ok)
+		// the fallback is dropped once the server is back
+		_, ok = fallback.Get("key")
+		require.False(t, ok)
+	})
+	t.Run("no_fallback", func(t *testing.T) {
+		t.Parallel()
+		down := newTestRedis(t, "")
+		down.Close()
+		c := NewRedisCacher(down.Addr(), WithRedisTimeout(100*time.Millisecond))
+		c.Set("key", "value")
+		_, ok := c.Get("key")
+		require.False(t, ok)
+		require.False(t, c.Delete("key"))
+		require.Zero(t, c.DeletePrefix("key"))
+	})
+}
+
+func TestReadReply(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		reply interface{}
+		err   string
+	}{
+		{name: "simple_string", input: "+OK\r\n", reply: "OK"},
+		{name: "error", input: "-ERR bad\r\n", err: "redis: ERR bad"},
+		{name: "integer", input: ":42\r\n", reply: int64(42)},
+		{name: "bulk", input: "$5\r\nhello\r\n", reply: "hello"},
+		{name: "nil_bulk", input: "$-1\r\n", reply: nil},
+		{name: "array", input: "*2\r\n$1\r\na\r\n:1\r\n", reply: []interface{}{"a", int64(1)}},
+		{name: "nested_array", input: "*2\r\n$1\r\n0\r\n*1\r\n$1\r\nk\r\n", reply: []interface{}{"0", []interface{}{"k"}}},
+		{name: "invalid", input: "?\r\n", err: "unexpected reply"},
+		{name: "truncated", input: "$5\r\nhel", err: "EOF"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reply, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.reply, reply)
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, `comics_title\*\?\[a\]\\`, escapeGlob(`comics_title*?[a]\`))
+}
@@ -0,0 +1,187 @@
+package cacher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// shardedCache splits the keys between caches having their own lock, so that concurrent calls don't contend on one lock
+type shardedCache struct {
+	shards          []*cache
+	cleanupInterval time.Duration
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// ErrSharedEvictionPolicy is returned when the shards of a cache would share the policy of WithEvictionPolicy
+var ErrSharedEvictionPolicy = errors.New("cacher: shards can't share an eviction policy, use WithEvictionPolicyFunc")
+
+// NewShardedCacher create a cache split into shards, the keys are distributed by their FNV-1a hash
+// the options apply to every shard, the bounds of WithMaxEntries and WithMaxBytes are split between the shards
+// every shard needs its own eviction policy, WithEvictionPolicyFunc creates one per shard
+// WithEvictionPolicy fails with ErrSharedEvictionPolicy when there are several shards
+func NewShardedCacher(shards int, opts ...Option) (Cacher, error) {
+	if shards < 1 {
+		shards = 1
+	}
+	c := &shardedCache{
+		shards: make([]*cache, shards),
+		stop:   make(chan struct{}),
+	}
+	for i := range c.shards {
+		shard := newCache(opts...)
+		if i > 0 && shard.policy != nil && shard.policy == c.shards[0].policy {
+			return nil, fmt.Errorf("%w: %d shards", ErrSharedEvictionPolicy, shards)
+		}
+		shard.maxEntries = int(splitBound(int64(shard.maxEntries), shards))
+		shard.maxBytes = splitBound(shard.maxBytes, shards)
+		c.shards[i] = shard
+	}
+	// one janitor cleans up all the shards
+	c.cleanupInterval = c.shards[0].cleanupInterval
+	if c.cleanupInterval > 0 {
+		go c.janitor()
+	}
+	return c, nil
+}
+
+// splitBound is the bound of a shard, rounded up so that the shards hold at least bound
+func splitBound(bound int64, shards int) int64 {
+	return (bound + int64(shards) - 1) / int64(shards)
+}
+
+func (c *shardedCache) shard(key string) *cache {
+	// inlined FNV-1a, hash/fnv would allocate on every call
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return c.shards[h%uint32(len(c.shards))]
+}
+
+// Get get cache from key
+func (c *shardedCache) Get(key string) (string, bool) {
+	return c.shard(key).Get(key)
+}
+
+// Set set cache key with value
+func (c *shardedCache) Set(key string, value string) {
+	c.shard(key).Set(key, value)
+}
+
+// SetWithTTL set cache key with value expiring after ttl
+func (c *shardedCache) SetWithTTL(key string, value string, ttl time.Duration) {
+	c.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// Delete delete cache key
+func (c *shardedCache) Delete(key string) bool {
+	return c.shard(key).Delete(key)
+}
+
+// DeletePrefix delete all cache keys starting with prefix
+func (c *shardedCache) DeletePrefix(prefix string) int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.DeletePrefix(prefix)
+	}
+	return n
+}
+
+// Clear delete all cache keys
+func (c *shardedCache) Clear() {
+	for _, shard := range c.shards {
+		shard.Clear()
+	}
+}
+
+// EvictionStats returns the number of entries evicted and expired so far
+func (c *shardedCache) EvictionStats() EvictionStats {
+	var stats EvictionStats
+	for _, shard := range c.shards {
+		shardStats := shard.EvictionStats()
+		stats.Evicted += shardStats.Evicted
+		stats.Expired += shardStats.Expired
+	}
+	return stats
+}
+
+// Stats returns the counters and the size of all the shards
+func (c *shardedCache) Stats() Stats {
+	var stats Stats
+	for _, shard := range c.shards {
+		shardStats := shard.Stats()
+		stats.Hits += shardStats.Hits
+		stats.Misses += shardStats.Misses
+		stats.Sets += shardStats.Sets
+		stats.Evicted += shardStats.Evicted
+		stats.Expired += shardStats.Expired
+		stats.Entries += shardStats.Entries
+		stats.Bytes += shardStats.Bytes
+	}
+	return stats
+}
+
+// Keys returns up to limit alive keys starting with prefix in order, and the number of such keys
+func (c *shardedCache) Keys(prefix string, limit int) ([]string, int) {
+	var keys []string
+	for _, shard := range c.shards {
+		shardKeys, _ := shard.Keys(prefix, 0)
+		keys = append(keys, shardKeys...)
+	}
+	sort.Strings(keys)
+	total := len(keys)
+	if limit > 0 && total > limit {
+		keys = keys[:limit]
+	}
+	return keys, total
+}
+
+// Inspect returns the info of an alive key
+func (c *shardedCache) Inspect(key string) (KeyInfo, bool) {
+	return c.shard(key).Inspect(key)
+}
+
+// WriteSnapshot writes all the alive keys of the shards to w
+func (c *shardedCache) WriteSnapshot(w io.Writer) error {
+	entries := make(map[string]entry)
+	for _, shard := range c.shards {
+		for key, e := range shard.aliveEntries() {
+			entries[key] = e
+		}
+	}
+	return writeSnapshot(w, entries)
+}
+
+// ReadSnapshot sets the alive keys of the snapshot read from r into their shard
+func (c *shardedCache) ReadSnapshot(r io.Reader) (int, error) {
+	return readSnapshot(r, c, c.shards[0].clock())
+}
+
+// Close stops the background janitor
+func (c *shardedCache) Close() error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+func (c *shardedCache) janitor() {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			for _, shard := range c.shards {
+				shard.deleteExpired()
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package cacher
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newShardedCacher creates a sharded cache with options which can't fail
+func newShardedCacher(t testing.TB, shards int, opts ...Option) Cacher {
+	c, err := NewShardedCacher(shards, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestShardedCacher(t *testing.T) {
+	t.Parallel()
+	t.Run("get_set_delete", func(t *testing.T) {
+		t.Parallel()
+		c := newShardedCacher(t, 4).(*shardedCache)
+		for i := 0; i < 100; i++ {
+			c.Set("key_"+strconv.Itoa(i), strconv.Itoa(i))
+		}
+		c.Set("other", "value")
+		for i := 0; i < 100; i++ {
+			v, ok := c.Get("key_" + strconv.Itoa(i))
+			require.True(t, ok)
+			require.Equal(t, strconv.Itoa(i), v)
+		}
+		// the keys are spread over all the shards
+		for _, shard := range c.shards {
+			require.NotEmpty(t, shard.storage)
+		}
+		require.True(t, c.Delete("key_0"))
+		require.False(t, c.Delete("key_0"))
+		require.Equal(t, 99, c.DeletePrefix("key_"))
+		keys, total := c.Keys("", 0)
+		require.Equal(t, []string{"other"}, keys)
+		require.Equal(t, 1, total)
+		c.Clear()
+		_, ok := c.Get("other")
+		require.False(t, ok)
+		require.Equal(t, Stats{Hits: 100, Misses: 1, Sets: 101}, c.Stats())
+	})
+	t.Run("bounds", func(t *testing.T) {
+		t.Parallel()
+		c := newShardedCacher(t, 4, WithMaxEntries(10), WithMaxBytes(1000)).(*shardedCache)
+		for _, shard := range c.shards {
+			require.Equal(t, 3, shard.maxEntries)
+			require.Equal(t, int64(250), shard.maxBytes)
+			require.NotNil(t, shard.policy)
+		}
+		for i := 0; i < 100; i++ {
+			c.Set(strconv.Itoa(i), "value")
+		}
+		require.LessOrEqual(t, c.Stats().Entries, 12)
+		require.Equal(t, uint64(100-c.Stats().Entries), c.EvictionStats().Evicted)
+	})
+	t.Run("policy", func(t *testing.T) {
+		t.Parallel()
+		c := newShardedCacher(t, 2, WithMaxEntries(2), WithEvictionPolicyFunc(NewLFUPolicy)).(*shardedCache)
+		require.NotSame(t, c.shards[0].policy, c.shards[1].policy)
+		_, err := NewShardedCacher(2, WithEvictionPolicy(NewLRUPolicy()))
+		require.ErrorIs(t, err, ErrSharedEvictionPolicy)
+		// a single shard may have the policy
+		_, err = NewShardedCacher(1, WithEvictionPolicy(NewLRUPolicy()))
+		require.NoError(t, err)
+	})
+	t.Run("snapshot", func(t *testing.T) {
+		t.Parallel()
+		c := newShardedCacher(t, 4)
+		c.Set("forever", "value")
+		c.SetWithTTL("alive", "value", time.Hour)
+		var buf bytes.Buffer
+		require.NoError(t, c.(Snapshotter).WriteSnapshot(&buf))
+
+		// the snapshot doesn't depend on the number of shards
+		restored := NewCacher()
+		n, err := restored.(Snapshotter).ReadSnapshot(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		resharded := newShardedCacher(t, 3)
+		n, err = resharded.(Snapshotter).ReadSnapshot(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		v, ok := resharded.Get("alive")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+	})
+	t.Run("cleanup", func(t *testing.T) {
+		t.Parallel()
+		c := newShardedCacher(t, 2, WithCleanupInterval(5*time.Millisecond)).(*shardedCache)
+		defer c.Close()
+		c.SetWithTTL("key", "value", time.Millisecond)
+		require.Eventually(t, func() bool {
+			return c.Stats().Entries == 0
+		}, time.Second, 5*time.Millisecond)
+		require.NoError(t, c.Close())
+	})
+	t.Run("stress", func(t *testing.T) {
+		t.Parallel()
+		n := 1000
+		c := newShardedCacher(t, 8, WithMaxEntries(50))
+		var wg sync.WaitGroup
+		wg.Add(2 * n)
+		for i := 0; i < n; i++ {
+			cacheKey := "test_stress_" + strconv.Itoa(i%100)
+			go func() {
+				defer wg.Done()
+				c.Set(cacheKey, cacheKey)
+			}()
+			go func() {
+				defer wg.Done()
+				c.Get(cacheKey)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// BenchmarkCacher compares the cache with the sharded cache under concurrent reads and writes
+// e.g. go test ./cacher -run ^$ -bench Cacher -cpu 1,4,16
+func BenchmarkCacher(b *testing.B) {
+	const keys = 10000
+	cachers := []struct {
+		name      string
+		newCacher func() Cacher
+	}{
+		{name: "cache", newCacher: func() Cacher { return NewCacher() }},
+		{name: "sharded_16", newCacher: func() Cacher { return newShardedCacher(b, 16) }},
+		{name: "bounded_cache", newCacher: func() Cacher { return NewCacher(WithMaxEntries(keys / 2)) }},
+		{name: "bounded_sharded_16", newCacher: func() Cacher { return newShardedCacher(b, 16, WithMaxEntries(keys/2)) }},
+	}
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = "character_info_" + strconv.Itoa(i)
+	}
+	for _, cc := range cachers {
+		// the percentage of writes of the workload
+		for _, writes := range []int{0, 10, 50} {
+			b.Run(cc.name+"/writes_"+strconv.Itoa(writes), func(b *testing.B) {
+				c := cc.newCacher()
+				for _, key := range names {
+					c.Set(key, key)
+				}
+				var goroutines int64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					// every goroutine starts on different keys
+					i := int(atomic.AddInt64(&goroutines, 1)) * keys / 64
+					for pb.Next() {
+						key := names[(i*7919)%keys]
+						if i%100 < writes {
+							c.Set(key, key)
+						} else {
+							c.Get(key)
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package cacher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot_Version is the version of the snapshot format written by WriteSnapshot
+//
+// the format is: magic, version (uint16), entry count (uint64), entries, crc32 of all the previous bytes
+// an entry is: key length (uvarint), key, value length (uvarint), value, expiry in unix nanoseconds (int64, 0 never)
+const Snapshot_Version = 1
+
+var (
+	ErrSnapshotCorrupted = errors.New("cache snapshot corrupted")
+	ErrSnapshotVersion   = errors.New("unsupported cache snapshot version")
+	// ErrSnapshotUnsupported is returned when the cacher can't be snapshotted, e.g. a redis cacher
+	ErrSnapshotUnsupported = errors.New("cache snapshot unsupported")
+)
+
+var snapshotMagic = []byte("XPCACHE")
+
+// Snapshotter is a Cacher which content can be saved and restored
+type Snapshotter interface {
+	// WriteSnapshot writes all the alive keys to w
+	WriteSnapshot(w io.Writer) error
+	// ReadSnapshot sets the alive keys of the snapshot read from r and returns how many were set
+	// nothing is set if the snapshot is corrupted
+	ReadSnapshot(r io.Reader) (int, error)
+}
+
+// SaveSnapshot writes the snapshot of c to the file at path
+// the file is replaced atomically, a crash never leaves a partial snapshot
+func SaveSnapshot(c Cacher, path string) error {
+	snapshotter, ok := c.(Snapshotter)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// the temporary file is removed in every case, once renamed there is nothing left to remove
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	err = snapshotter.WriteSnapshot(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadSnapshot restores c from the snapshot file at path and returns the number of restored keys
+func LoadSnapshot(c Cacher, path string) (int, error) {
+	snapshotter, ok := c.(Snapshotter)
+	if !ok {
+		return 0, ErrSnapshotUnsupported
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return snapshotter.ReadSnapshot(bufio.NewReader(f))
+}
+
+// WriteSnapshot writes all the alive keys of the cache to w
+func (c *cache) WriteSnapshot(w io.Writer) error {
+	return writeSnapshot(w, c.aliveEntries())
+}
+
+// ReadSnapshot sets the alive keys of the snapshot read from r
+// the keys are set after the whole snapshot is verified
+func (c *cache) ReadSnapshot(r io.Reader) (int, error) {
+	return readSnapshot(r, c, c.clock())
+}
+
+// aliveEntries copies the entries which are not expired
+func (c *cache) aliveEntries() map[string]entry {
+	now := c.clock()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entries := make(map[string]entry, len(c.storage))
+	for key, e := range c.storage {
+		if !e.expired(now) {
+			entries[key] = e
+		}
+	}
+	return entries
+}
+
+func writeSnapshot(w io.Writer, entries map[string]entry) error {
+	hash := crc32.NewIEEE()
+	mw := io.MultiWriter(w, hash)
+	header := make([]byte, len(snapshotMagic)+2+8)
+	copy(header, snapshotMagic)
+	binary.BigEndian.PutUint16(header[len(snapshotMagic):], Snapshot_Version)
+	binary.BigEndian.PutUint64(header[len(snapshotMagic)+2:], uint64(len(entries)))
+	if _, err := mw.Write(header); err != nil {
+		return err
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	for key, e := range entries {
+		if err := writeBytes(mw, buf, key); err != nil {
+			return err
+		}
+		if err := writeBytes(mw, buf, e.value); err != nil {
+			return err
+		}
+		var expiresAt int64
+		if !e.expiresAt.IsZero() {
+			expiresAt = e.expiresAt.UnixNano()
+		}
+		binary.BigEndian.PutUint64(buf, uint64(expiresAt))
+		if _, err := mw.Write(buf[:8]); err != nil {
+			return err
+		}
+	}
+	binary.BigEndian.PutUint32(buf, hash.Sum32())
+	_, err := w.Write(buf[:4])
+	return err
+}
+
+// readSnapshot verifies the snapshot read from r, then sets its keys alive at now in c
+func readSnapshot(r io.Reader, c Cacher, now time.Time) (int, error) {
+	hash := crc32.NewIEEE()
+	tr := io.TeeReader(r, hash)
+	header := make([]byte, len(snapshotMagic)+2+8)
+	if _, err := io.ReadFull(tr, header); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+	}
+	if !bytes.Equal(header[:len(snapshotMagic)], snapshotMagic) {
+		return 0, fmt.Errorf("%w: invalid magic", ErrSnapshotCorrupted)
+	}
+	if version := binary.BigEndian.Uint16(header[len(snapshotMagic):]); version != Snapshot_Version {
+		return 0, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+	count := binary.BigEndian.Uint64(header[len(snapshotMagic)+2:])
+	br := &byteReader{r: tr}
+	var keys []string
+	var entries []entry
+	for i := uint64(0); i < count; i++ {
+		key, err := readBytes(br)
+		if err != nil {
+			return 0, err
+		}
+		value, err := readBytes(br)
+		if err != nil {
+			return 0, err
+		}
+		b := make([]byte, 8)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+		}
+		e := entry{value: value}
+		if expiresAt := int64(binary.BigEndian.Uint64(b)); expiresAt != 0 {
+			e.expiresAt = time.Unix(0, expiresAt)
+		}
+		keys = append(keys, key)
+		entries = append(entries, e)
+	}
+	sum := hash.Sum32()
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+	}
+	if binary.BigEndian.Uint32(b) != sum {
+		return 0, fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupted)
+	}
+
+	n := 0
+	for i, key := range keys {
+		e := entries[i]
+		if e.expired(now) {
+			continue
+		}
+		ttl := time.Duration(0)
+		if !e.expiresAt.IsZero() {
+			ttl = e.expiresAt.Sub(now)
+		}
+		c.SetWithTTL(key, e.value, ttl)
+		n++
+	}
+	return n, nil
+}
+
+func writeBytes(w io.Writer, buf []byte, s string) error {
+	n := binary.PutUvarint(buf, uint64(len(s)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readBytes(r *byteReader) (string, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+	}
+	// the buffer grows with the data actually read, a corrupted length doesn't allocate it upfront
+	var b bytes.Buffer
+	if _, err := io.CopyN(&b, r, int64(size)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+	}
+	return b.String(), nil
+}
+
+// byteReader adds io.ByteReader to a reader for binary.ReadUvarint
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (r *byteReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.r, r.buf[:]); err != nil {
+		return 0, err
+	}
+	return r.buf[0], nil
+}
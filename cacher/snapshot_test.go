@@ -0,0 +1,112 @@
+package cacher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCache := func() *cache {
+		c := NewCacher().(*cache)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	snapshot := func(t *testing.T) []byte {
+		c := newCache()
+		c.SetWithTTL("forever", "value", 0)
+		c.SetWithTTL("alive", "value\x00with binary", time.Hour)
+		c.SetWithTTL("expired", "value", time.Second)
+		c.storage["expired"] = entry{value: "value", expiresAt: now.Add(-time.Second)}
+		var buf bytes.Buffer
+		require.NoError(t, c.WriteSnapshot(&buf))
+		return buf.Bytes()
+	}
+	t.Run("round_trip", func(t *testing.T) {
+		t.Parallel()
+		c := newCache()
+		n, err := c.ReadSnapshot(bytes.NewReader(snapshot(t)))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		v, ok := c.Get("alive")
+		require.True(t, ok)
+		require.Equal(t, "value\x00with binary", v)
+		require.Equal(t, now.Add(time.Hour), c.storage["alive"].expiresAt)
+		require.True(t, c.storage["forever"].expiresAt.IsZero())
+		_, ok = c.Get("expired")
+		require.False(t, ok)
+	})
+	t.Run("expired_while_stopped", func(t *testing.T) {
+		t.Parallel()
+		c := NewCacher().(*cache)
+		c.now = func() time.Time { return now.Add(2 * time.Hour) }
+		n, err := c.ReadSnapshot(bytes.NewReader(snapshot(t)))
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		_, ok := c.Get("alive")
+		require.False(t, ok)
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		t.Parallel()
+		b := snapshot(t)
+		corrupted := map[string][]byte{
+			"empty":     {},
+			"magic":     append([]byte("INVALID"), b[len(snapshotMagic):]...),
+			"truncated": b[:len(b)-3],
+			"flipped":   append(append(append([]byte{}, b[:20]...), b[20]^0xff), b[21:]...),
+		}
+		for name, data := range corrupted {
+			c := newCache()
+			_, err := c.ReadSnapshot(bytes.NewReader(data))
+			require.ErrorIs(t, err, ErrSnapshotCorrupted, name)
+			require.Empty(t, c.storage, name)
+		}
+	})
+	t.Run("version", func(t *testing.T) {
+		t.Parallel()
+		b := snapshot(t)
+		binary.BigEndian.PutUint16(b[len(snapshotMagic):], Snapshot_Version+1)
+		_, err := newCache().ReadSnapshot(bytes.NewReader(b))
+		require.ErrorIs(t, err, ErrSnapshotVersion)
+	})
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "cache.snapshot")
+		c := NewCacher()
+		c.Set("key", "value")
+		require.NoError(t, SaveSnapshot(c, path))
+		c.Set("key", "new")
+		// the previous snapshot is replaced
+		require.NoError(t, SaveSnapshot(c, path))
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		restored := NewCacher()
+		n, err := LoadSnapshot(restored, path)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		v, _ := restored.Get("key")
+		require.Equal(t, "new", v)
+
+		_, err = LoadSnapshot(restored, filepath.Join(dir, "missing"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Error(t, SaveSnapshot(c, filepath.Join(dir, "missing", "cache.snapshot")))
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		c := NewRedisCacher("127.0.0.1:0")
+		require.ErrorIs(t, SaveSnapshot(c, "cache.snapshot"), ErrSnapshotUnsupported)
+		_, err := LoadSnapshot(c, "cache.snapshot")
+		require.ErrorIs(t, err, ErrSnapshotUnsupported)
+	})
+}
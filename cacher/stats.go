@@ -0,0 +1,151 @@
+package cacher
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the counters and the size of a cache
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Sets    uint64 `json:"sets"`
+	Evicted uint64 `json:"evicted"`
+	Expired uint64 `json:"expired"`
+	// Entries includes the expired keys not removed yet
+	Entries int `json:"entries"`
+	// Bytes is the approximate size of the keys and values
+	Bytes int64 `json:"bytes"`
+	// L1Entries and L1Bytes are the size of the first tier of a tiered cache, Entries and Bytes are the size of the second tier
+	// the first tier holds copies of second tier keys, they aren't counted twice
+	L1Entries int   `json:"l1Entries,omitempty"`
+	L1Bytes   int64 `json:"l1Bytes,omitempty"`
+}
+
+// StatsReporter is a Cacher which reports its stats
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// KeyInfo describes a cached key
+type KeyInfo struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Size is the approximate size of the key and value
+	Size int64 `json:"size"`
+	// ExpiresAt is zero when the key never expires
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Inspector is a Cacher which keys can be listed and inspected
+type Inspector interface {
+	// Keys returns up to limit alive keys starting with prefix in order, and the number of such keys
+	// all the keys are returned when limit is 0
+	Keys(prefix string, limit int) ([]string, int)
+	// Inspect returns the info of an alive key
+	Inspect(key string) (KeyInfo, bool)
+}
+
+// Stats returns the counters and the size of the cache
+func (c *cache) Stats() Stats {
+	c.lock.RLock()
+	entries, bytes := len(c.storage), c.bytes
+	c.lock.RUnlock()
+	return Stats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Sets:    atomic.LoadUint64(&c.sets),
+		Evicted: atomic.LoadUint64(&c.evicted),
+		Expired: atomic.LoadUint64(&c.expired),
+		Entries: entries,
+		Bytes:   bytes,
+	}
+}
+
+// Keys returns up to limit alive keys starting with prefix in order, and the number of such keys
+func (c *cache) Keys(prefix string, limit int) ([]string, int) {
+	now := c.clock()
+	c.lock.RLock()
+	var keys []string
+	for key, e := range c.storage {
+		if strings.HasPrefix(key, prefix) && !e.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	c.lock.RUnlock()
+	sort.Strings(keys)
+	total := len(keys)
+	if limit > 0 && total > limit {
+		keys = keys[:limit]
+	}
+	return keys, total
+}
+
+// Inspect returns the info of an alive key, it isn't a use of the key for the eviction policy
+func (c *cache) Inspect(key string) (KeyInfo, bool) {
+	c.lock.RLock()
+	e, ok := c.storage[key]
+	c.lock.RUnlock()
+	if !ok || e.expired(c.clock()) {
+		return KeyInfo{}, false
+	}
+	return KeyInfo{
+		Key:       key,
+		Value:     e.value,
+		Size:      entrySize(key, e),
+		ExpiresAt: e.expiresAt,
+	}, true
+}
+
+// Stats returns the reads and writes of the tiered cache
+// the removed keys are summed over the tiers reporting their stats
+// the size is the size of the second tier which holds all the keys, the size of the first tier is reported apart
+func (c *tieredCache) Stats() Stats {
+	tiers := c.TierStats()
+	stats := Stats{
+		Hits:   tiers.L1Hits + tiers.L2Hits,
+		Misses: tiers.Misses,
+		Sets:   tiers.Writes,
+	}
+	if reporter, ok := c.l1.(StatsReporter); ok {
+		l1Stats := reporter.Stats()
+		stats.Evicted += l1Stats.Evicted
+		stats.Expired += l1Stats.Expired
+		stats.L1Entries = l1Stats.Entries
+		stats.L1Bytes = l1Stats.Bytes
+	}
+	if reporter, ok := c.l2.(StatsReporter); ok {
+		l2Stats := reporter.Stats()
+		stats.Evicted += l2Stats.Evicted
+		stats.Expired += l2Stats.Expired
+		stats.Entries = l2Stats.Entries
+		stats.Bytes = l2Stats.Bytes
+	}
+	return stats
+}
+
+// Keys lists the keys of the second tier which holds all the keys, or of the first tier if the second can't be listed
+func (c *tieredCache) Keys(prefix string, limit int) ([]string, int) {
+	c.Flush()
+	for _, tier := range []Cacher{c.l2, c.l1} {
+		if inspector, ok := tier.(Inspector); ok {
+			return inspector.Keys(prefix, limit)
+		}
+	}
+	return nil, 0
+}
+
+// Inspect returns the info of key from the first tier having it
+func (c *tieredCache) Inspect(key string) (KeyInfo, bool) {
+	c.Flush()
+	for _, tier := range []Cacher{c.l1, c.l2} {
+		if inspector, ok := tier.(Inspector); ok {
+			if info, ok := inspector.Inspect(key); ok {
+				return info, true
+			}
+		}
+	}
+	return KeyInfo{}, false
+}
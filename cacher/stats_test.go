@@ -0,0 +1,84 @@
+package cacher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacher(WithMaxEntries(2)).(*cache)
+		c.now = func() time.Time { return now }
+		c.Set("a", "1")
+		c.SetWithTTL("b", "22", time.Second)
+		c.Get("b")
+		c.Get("missing")
+		// evicts a, the least recently used key
+		c.Set("c", "333")
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Sets: 3, Evicted: 1, Entries: 2, Bytes: 7}, c.Stats())
+
+		now = now.Add(2 * time.Second)
+		c.Get("b")
+		require.Equal(t, Stats{Hits: 1, Misses: 2, Sets: 3, Evicted: 1, Expired: 1, Entries: 1, Bytes: 4}, c.Stats())
+	})
+	t.Run("inspect", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewCacher().(*cache)
+		c.now = func() time.Time { return now }
+		c.Set("character_info_2", "b")
+		c.Set("character_info_1", "a")
+		c.SetWithTTL("characters", "[1,2]", time.Hour)
+		c.SetWithTTL("character_info_3", "c", time.Second)
+		c.storage["character_info_3"] = entry{value: "c", expiresAt: now.Add(-time.Second)}
+
+		keys, total := c.Keys("character_info_", 0)
+		require.Equal(t, []string{"character_info_1", "character_info_2"}, keys)
+		require.Equal(t, 2, total)
+		keys, total = c.Keys("", 1)
+		require.Equal(t, []string{"character_info_1"}, keys)
+		require.Equal(t, 3, total)
+
+		info, ok := c.Inspect("characters")
+		require.True(t, ok)
+		require.Equal(t, KeyInfo{Key: "characters", Value: "[1,2]", Size: 15, ExpiresAt: now.Add(time.Hour)}, info)
+		_, ok = c.Inspect("character_info_3")
+		require.False(t, ok)
+		// inspecting isn't a read of the cache
+		require.Zero(t, c.Stats().Hits)
+	})
+	t.Run("tiered", func(t *testing.T) {
+		t.Parallel()
+		l1, l2 := NewCacher(), NewCacher()
+		c := NewTieredCacher(l1, l2, WithWriteBehind(10)).(*tieredCache)
+		defer c.Close()
+		l2.Set("l2", "value")
+		c.Set("both", "value")
+		c.Get("l2")
+		c.Get("missing")
+		c.Flush()
+
+		stats := c.Stats()
+		require.Equal(t, uint64(1), stats.Hits)
+		require.Equal(t, uint64(1), stats.Misses)
+		require.Equal(t, uint64(1), stats.Sets)
+		// both keys are in both tiers once the promotion and the queued write are done
+		// the copies of the first tier aren't counted in the entries
+		require.Equal(t, 2, stats.Entries)
+		require.Equal(t, 2, stats.L1Entries)
+		require.Equal(t, l2.(StatsReporter).Stats().Bytes, stats.Bytes)
+		require.Equal(t, stats.Bytes, stats.L1Bytes)
+
+		keys, total := c.Keys("", 0)
+		require.Equal(t, []string{"both", "l2"}, keys)
+		require.Equal(t, 2, total)
+		info, ok := c.Inspect("l2")
+		require.True(t, ok)
+		require.Equal(t, "value", info.Value)
+	})
+}
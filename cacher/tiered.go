@@ -0,0 +1,255 @@
+package cacher
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TieredOption configures the cache created by NewTieredCacher
+type TieredOption func(*tieredCache)
+
+// WithL1TTL caps the ttl of the keys in the first tier, so that it follows the changes of a shared second tier
+func WithL1TTL(ttl time.Duration) TieredOption {
+	return func(c *tieredCache) {
+		c.l1TTL = ttl
+	}
+}
+
+// WithWriteBehind writes the second tier asynchronously through a queue of queueSize writes
+// the writers wait while the queue is full, by default both tiers are written synchronously
+func WithWriteBehind(queueSize int) TieredOption {
+	return func(c *tieredCache) {
+		c.queueSize = queueSize
+	}
+}
+
+// TierStats counts the reads served by each tier
+type TierStats struct {
+	L1Hits uint64 `json:"l1Hits"`
+	L2Hits uint64 `json:"l2Hits"`
+	Misses uint64 `json:"misses"`
+	// Promotions is the number of second tier hits copied into the first tier
+	Promotions uint64 `json:"promotions"`
+	// Writes is the number of keys set, WriteBehind of them went through the queue
+	Writes      uint64 `json:"writes"`
+	WriteBehind uint64 `json:"writeBehind"`
+}
+
+// tieredWrite is a pending write of the second tier, flushed is closed once the writes queued before are done
+type tieredWrite struct {
+	key   string
+	value string
+	ttl   time.Duration
+	// defaultTTL writes with the default ttl of the tier instead of ttl
+	defaultTTL bool
+	flushed    chan struct{}
+}
+
+type tieredCache struct {
+	// the counters are accessed atomically, they are kept first for 64-bit alignment
+	stats TierStats
+
+	l1 Cacher
+	l2 Cacher
+	// l1TTL is 0 when the first tier keeps the ttl of the keys
+	l1TTL     time.Duration
+	queueSize int
+	queue     chan tieredWrite
+	done      chan struct{}
+	// closing guards closed, writes aren't queued once the cache is closed
+	closing   sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewTieredCacher create a cache layering l1, a small fast cache, over l2, a slower shared or persistent one
+// reads fall through to l2 and promote its hits into l1, writes go to both tiers
+func NewTieredCacher(l1, l2 Cacher, opts ...TieredOption) Cacher {
+	c := &tieredCache{
+		l1: l1,
+		l2: l2,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.queueSize > 0 {
+		c.queue = make(chan tieredWrite, c.queueSize)
+		c.done = make(chan struct{})
+		go c.writer()
+	}
+	return c
+}
+
+// Get get cache from the first tier having key
+func (c *tieredCache) Get(key string) (string, bool) {
+	if v, ok := c.l1.Get(key); ok {
+		atomic.AddUint64(&c.stats.L1Hits, 1)
+		return v, true
+	}
+	v, ok := c.l2.Get(key)
+	if !ok {
+		atomic.AddUint64(&c.stats.Misses, 1)
+		return "", false
+	}
+	atomic.AddUint64(&c.stats.L2Hits, 1)
+	atomic.AddUint64(&c.stats.Promotions, 1)
+	// the remaining ttl in l2 is unknown, l1 keeps the key for its own ttl
+	if c.l1TTL > 0 {
+		c.l1.SetWithTTL(key, v, c.l1TTL)
+	} else {
+		c.l1.Set(key, v)
+	}
+	return v, true
+}
+
+// Set set cache key with value in both tiers with their default ttl
+func (c *tieredCache) Set(key string, value string) {
+	atomic.AddUint64(&c.stats.Writes, 1)
+	if c.l1TTL > 0 {
+		c.l1.SetWithTTL(key, value, c.l1TTL)
+	} else {
+		c.l1.Set(key, value)
+	}
+	c.writeL2(tieredWrite{key: key, value: value, defaultTTL: true})
+}
+
+// SetWithTTL set cache key with value in both tiers expiring after ttl
+func (c *tieredCache) SetWithTTL(key string, value string, ttl time.Duration) {
+	atomic.AddUint64(&c.stats.Writes, 1)
+	c.l1.SetWithTTL(key, value, c.capL1TTL(ttl))
+	c.writeL2(tieredWrite{key: key, value: value, ttl: ttl})
+}
+
+// writeL2 queues the write in write behind mode, otherwise it writes the second tier right away
+// the writes of the second tier are applied in order, so that an older write never overwrites a newer one
+func (c *tieredCache) writeL2(w tieredWrite) {
+	if c.queue != nil {
+		if c.enqueue(w) {
+			atomic.AddUint64(&c.stats.WriteBehind, 1)
+			return
+		}
+		// closed, the writes queued before must land first
+		c.Flush()
+	}
+	c.apply(w)
+}
+
+// enqueue queues w unless the cache is closed, it waits while the queue is full
+func (c *tieredCache) enqueue(w tieredWrite) bool {
+	c.closing.RLock()
+	defer c.closing.RUnlock()
+	if c.closed {
+		return false
+	}
+	// the queue is full, slow down the writer instead of dropping the write
+	// Close waits for the lock, the writer keeps draining the queue meanwhile
+	c.queue <- w
+	return true
+}
+
+func (c *tieredCache) apply(w tieredWrite) {
+	if w.defaultTTL {
+		c.l2.Set(w.key, w.value)
+		return
+	}
+	c.l2.SetWithTTL(w.key, w.value, w.ttl)
+}
+
+// Delete delete cache key from both tiers
+func (c *tieredCache) Delete(key string) bool {
+	// a pending write must not bring the key back
+	c.Flush()
+	deleted := c.l1.Delete(key)
+	return c.l2.Delete(key) || deleted
+}
+
+// DeletePrefix delete all cache keys starting with prefix from both tiers
+// it returns the number of keys deleted from the second tier, which holds all the keys
+func (c *tieredCache) DeletePrefix(prefix string) int {
+	c.Flush()
+	c.l1.DeletePrefix(prefix)
+	return c.l2.DeletePrefix(prefix)
+}
+
+// Clear delete all cache keys from both tiers
+func (c *tieredCache) Clear() {
+	c.Flush()
+	c.l1.Clear()
+	c.l2.Clear()
+}
+
+// TierStats returns the hit counters of the tiers
+func (c *tieredCache) TierStats() TierStats {
+	return TierStats{
+		L1Hits:      atomic.LoadUint64(&c.stats.L1Hits),
+		L2Hits:      atomic.LoadUint64(&c.stats.L2Hits),
+		Misses:      atomic.LoadUint64(&c.stats.Misses),
+		Promotions:  atomic.LoadUint64(&c.stats.Promotions),
+		Writes:      atomic.LoadUint64(&c.stats.Writes),
+		WriteBehind: atomic.LoadUint64(&c.stats.WriteBehind),
+	}
+}
+
+// Flush waits for the pending writes of the second tier
+func (c *tieredCache) Flush() {
+	if c.queue == nil {
+		return
+	}
+	flushed := make(chan struct{})
+	select {
+	case c.queue <- tieredWrite{flushed: flushed}:
+	case <-c.done:
+		// closed, there is no writer left to wait for
+		return
+	}
+	select {
+	case <-flushed:
+	case <-c.done:
+	}
+}
+
+// Close writes the pending writes, then closes both tiers
+func (c *tieredCache) Close() error {
+	c.closeOnce.Do(func() {
+		if c.queue != nil {
+			c.closing.Lock()
+			c.closed = true
+			c.closing.Unlock()
+			c.Flush()
+			close(c.done)
+		}
+		for _, tier := range []Cacher{c.l1, c.l2} {
+			if closer, ok := tier.(io.Closer); ok {
+				if err := closer.Close(); c.closeErr == nil {
+					c.closeErr = err
+				}
+			}
+		}
+	})
+	return c.closeErr
+}
+
+func (c *tieredCache) writer() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case w := <-c.queue:
+			if w.flushed != nil {
+				close(w.flushed)
+				continue
+			}
+			c.apply(w)
+		}
+	}
+}
+
+func (c *tieredCache) capL1TTL(ttl time.Duration) time.Duration {
+	if c.l1TTL > 0 && (ttl <= 0 || ttl > c.l1TTL) {
+		return c.l1TTL
+	}
+	return ttl
+}
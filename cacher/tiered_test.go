@@ -0,0 +1,156 @@
+package cacher
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingCacher blocks the writes of key, or all the writes when key is empty, until release is closed
+type blockingCacher struct {
+	Cacher
+	key     string
+	release chan struct{}
+}
+
+func (c *blockingCacher) SetWithTTL(key, value string, ttl time.Duration) {
+	if c.key == "" || c.key == key {
+		<-c.release
+	}
+	c.Cacher.SetWithTTL(key, value, ttl)
+}
+
+func TestTieredCacher(t *testing.T) {
+	t.Parallel()
+	t.Run("read_through", func(t *testing.T) {
+		t.Parallel()
+		l1, l2 := NewCacher().(*cache), NewCacher()
+		c := NewTieredCacher(l1, l2, WithL1TTL(time.Minute)).(*tieredCache)
+		l2.Set("key", "value")
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		// promoted into l1 with the l1 ttl
+		require.False(t, l1.storage["key"].expiresAt.IsZero())
+		v, ok = c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		_, ok = c.Get("missing")
+		require.False(t, ok)
+		require.Equal(t, TierStats{L1Hits: 1, L2Hits: 1, Misses: 1, Promotions: 1}, c.TierStats())
+	})
+	t.Run("write_through", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		l1, l2 := NewCacher().(*cache), NewCacher(WithDefaultTTL(time.Hour)).(*cache)
+		l1.now = func() time.Time { return now }
+		l2.now = func() time.Time { return now }
+		c := NewTieredCacher(l1, l2, WithL1TTL(time.Minute))
+		c.SetWithTTL("ttl", "value", 2*time.Hour)
+		require.Equal(t, now.Add(time.Minute), l1.storage["ttl"].expiresAt)
+		require.Equal(t, now.Add(2*time.Hour), l2.storage["ttl"].expiresAt)
+		c.SetWithTTL("short", "value", time.Second)
+		require.Equal(t, now.Add(time.Second), l1.storage["short"].expiresAt)
+		c.Set("default", "value")
+		require.Equal(t, now.Add(time.Minute), l1.storage["default"].expiresAt)
+		require.Equal(t, now.Add(time.Hour), l2.storage["default"].expiresAt)
+	})
+	t.Run("write_behind", func(t *testing.T) {
+		t.Parallel()
+		l2 := &blockingCacher{Cacher: NewCacher(), release: make(chan struct{})}
+		c := NewTieredCacher(NewCacher(), l2, WithWriteBehind(10)).(*tieredCache)
+		c.SetWithTTL("key", "value", 0)
+		// served from l1 while l2 is written
+		v, ok := c.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "value", v)
+		_, ok = l2.Get("key")
+		require.False(t, ok)
+		close(l2.release)
+		c.Flush()
+		_, ok = l2.Get("key")
+		require.True(t, ok)
+		require.EqualValues(t, 1, c.TierStats().WriteBehind)
+		require.NoError(t, c.Close())
+		require.NoError(t, c.Close())
+		// closed, the writes are synchronous
+		c.SetWithTTL("closed", "value", 0)
+		_, ok = l2.Get("closed")
+		require.True(t, ok)
+	})
+	t.Run("queue_full", func(t *testing.T) {
+		t.Parallel()
+		l2 := &blockingCacher{Cacher: NewCacher(), release: make(chan struct{})}
+		c := NewTieredCacher(NewCacher(), l2, WithWriteBehind(1)).(*tieredCache)
+		var wg sync.WaitGroup
+		wg.Add(3)
+		for i := 0; i < 3; i++ {
+			go func(i int) {
+				defer wg.Done()
+				c.SetWithTTL("key_"+strconv.Itoa(i), "value", 0)
+			}(i)
+		}
+		close(l2.release)
+		wg.Wait()
+		c.Flush()
+		for i := 0; i < 3; i++ {
+			_, ok := l2.Get("key_" + strconv.Itoa(i))
+			require.True(t, ok)
+		}
+		require.NoError(t, c.Close())
+	})
+	t.Run("queue_full_order", func(t *testing.T) {
+		t.Parallel()
+		l2 := &blockingCacher{Cacher: NewCacher(), key: "blocked", release: make(chan struct{})}
+		c := NewTieredCacher(NewCacher(), l2, WithWriteBehind(1)).(*tieredCache)
+		c.SetWithTTL("blocked", "value", 0)
+		// the writer is blocked on the first write, the next one fills the queue
+		require.Eventually(t, func() bool {
+			return len(c.queue) == 0
+		}, time.Second, time.Millisecond)
+		c.SetWithTTL("key", "old", 0)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.SetWithTTL("key", "new", 0)
+		}()
+		// the write waits for room in the queue
+		select {
+		case <-done:
+		case <-time.After(10 * time.Millisecond):
+		}
+		close(l2.release)
+		<-done
+		c.Flush()
+		// the newer write isn't overwritten by the one queued before
+		v, ok := l2.Get("key")
+		require.True(t, ok)
+		require.Equal(t, "new", v)
+		require.NoError(t, c.Close())
+	})
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		l1, l2 := NewCacher(), NewCacher()
+		c := NewTieredCacher(l1, l2, WithWriteBehind(10))
+		defer c.(*tieredCache).Close()
+		c.Set("character_info_1", "1")
+		c.Set("character_info_2", "2")
+		c.Set("characters", "[1,2]")
+		require.True(t, c.Delete("characters"))
+		require.False(t, c.Delete("characters"))
+		_, ok := l2.Get("characters")
+		require.False(t, ok)
+		require.Equal(t, 2, c.DeletePrefix("character_info_"))
+		_, ok = l1.Get("character_info_1")
+		require.False(t, ok)
+		c.Set("key", "value")
+		c.Clear()
+		_, ok = l1.Get("key")
+		require.False(t, ok)
+		_, ok = l2.Get("key")
+		require.False(t, ok)
+	})
+}
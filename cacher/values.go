@@ -0,0 +1,112 @@
+package cacher
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValueCache keeps the values decoded from a Cacher, so that a hit doesn't decode the same stored value again
+// a value is only returned for the exact stored value it was decoded from, a key set again is decoded again
+// the decoded values are shared by all the callers, they must not be modified
+type ValueCache struct {
+	lock       sync.RWMutex
+	values     map[string]decodedValue
+	maxEntries int
+	policy     EvictionPolicy
+}
+
+type decodedValue struct {
+	raw   string
+	value interface{}
+}
+
+// NewValueCache creates a cache of decoded values bounded to maxEntries, the least recently used are evicted
+// a nil ValueCache keeps no value
+func NewValueCache(maxEntries int) *ValueCache {
+	return &ValueCache{
+		values:     make(map[string]decodedValue),
+		maxEntries: maxEntries,
+		policy:     NewLRUPolicy(),
+	}
+}
+
+// Get returns the value decoded from raw, the value stored with key
+func (c *ValueCache) Get(key, raw string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	v, ok := c.values[key]
+	// raw is usually the very string the value was decoded from, the comparison doesn't read it then
+	if !ok || v.raw != raw {
+		return nil, false
+	}
+	c.policy.Access(key)
+	return v.value, true
+}
+
+// Set keeps value decoded from raw, the value stored with key
+func (c *ValueCache) Set(key, raw string, value interface{}) {
+	if c == nil || c.maxEntries <= 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.values[key]; ok {
+		c.policy.Access(key)
+	} else {
+		for len(c.values) >= c.maxEntries {
+			victim, ok := c.policy.Victim()
+			if !ok {
+				break
+			}
+			delete(c.values, victim)
+			c.policy.Remove(victim)
+		}
+		c.policy.Add(key)
+	}
+	c.values[key] = decodedValue{raw: raw, value: value}
+}
+
+// Delete drops the value of key
+func (c *ValueCache) Delete(key string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.values[key]; ok {
+		delete(c.values, key)
+		c.policy.Remove(key)
+	}
+}
+
+// Keys returns the keys starting with prefix which have a value, in order
+func (c *ValueCache) Keys(prefix string) []string {
+	if c == nil {
+		return nil
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var keys []string
+	for key := range c.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Clear drops all the values
+func (c *ValueCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values = make(map[string]decodedValue)
+	c.policy = NewLRUPolicy()
+}
@@ -0,0 +1,51 @@
+package cacher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCache(t *testing.T) {
+	t.Parallel()
+	c := NewValueCache(2)
+	value := &[]int{1, 2}
+	c.Set("a", "[1,2]", value)
+	v, ok := c.Get("a", "[1,2]")
+	require.True(t, ok)
+	require.Same(t, value, v)
+	// the key was set again with another value
+	_, ok = c.Get("a", "[1,2,3]")
+	require.False(t, ok)
+
+	c.Set("b", "b", "b")
+	c.Get("a", "[1,2]")
+	// evicts b, the least recently used value
+	c.Set("c", "c", "c")
+	_, ok = c.Get("b", "b")
+	require.False(t, ok)
+	_, ok = c.Get("a", "[1,2]")
+	require.True(t, ok)
+	require.Len(t, c.values, 2)
+	c.Delete("a")
+	_, ok = c.Get("a", "[1,2]")
+	require.False(t, ok)
+
+	c.Set("ab", "ab", "ab")
+	require.Equal(t, []string{"ab", "c"}, c.Keys(""))
+	require.Equal(t, []string{"ab"}, c.Keys("a"))
+	c.Clear()
+	require.Empty(t, c.Keys(""))
+	// the cleared cache keeps values again
+	c.Set("a", "a", "a")
+	_, ok = c.Get("a", "a")
+	require.True(t, ok)
+
+	var disabled *ValueCache
+	disabled.Set("a", "a", "a")
+	disabled.Delete("a")
+	disabled.Clear()
+	require.Empty(t, disabled.Keys(""))
+	_, ok = disabled.Get("a", "a")
+	require.False(t, ok)
+}
@@ -5,59 +5,247 @@ import (
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	API_LIMIT = 100
-	API_HOST  = "gateway.marvel.com"
+	API_LIMIT  = 100
+	API_HOST   = "gateway.marvel.com"
+	API_SCHEME = "https"
+
+	maxErrorBodySize = 64 << 10
 )
 
 // API defines api properties
 type API struct {
+	// stats is accessed atomically, keep it first for 64-bit alignment
+	stats           apiStats
+	scheme          string
 	host            string
 	apiPublicKey    string
 	apiPrivateKey   string
 	concurrentLimit int
 	wg              sync.WaitGroup
+
+	httpClient *http.Client
+	transport  http.RoundTripper
+	proxy      *url.URL
+	timeout    time.Duration
+	userAgent  string
+
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+	quota       *QuotaTracker
 }
 
 // NewAPI creates new api object
-func NewAPI(host, publicKey, privateKey string) *API {
+// by default it requests marvel over https with the default http client, use options to change it
+func NewAPI(host, publicKey, privateKey string, opts ...Option) *API {
 	if host == "" {
 		host = API_HOST
 	}
-	return &API{
+	api := &API{
+		scheme:          API_SCHEME,
 		host:            host,
 		apiPublicKey:    publicKey,
 		apiPrivateKey:   privateKey,
 		concurrentLimit: runtime.NumCPU(),
+		retryPolicy:     DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(api)
+	}
+	api.httpClient = buildHTTPClient(api.httpClient, api.transport, api.proxy)
+	return api
+}
+
+// get sends a signed GET request to marvel api path and decodes the response
+// failed requests are retried following the api retry policy
+func (api *API) get(ctx context.Context, path string, query url.Values) (*marvelAPIResult, error) {
+	for attempt := 1; ; attempt++ {
+		if err := api.acquire(ctx); err != nil {
+			atomic.AddUint64(&api.stats.failures, 1)
+			return nil, err
+		}
+		atomic.AddUint64(&api.stats.requests, 1)
+		result, err := api.doGet(ctx, path, query)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			atomic.AddUint64(&api.stats.failures, 1)
+			return nil, err
+		}
+		wait, ok := api.retryPolicy.nextAttempt(attempt, err)
+		if !ok {
+			atomic.AddUint64(&api.stats.failures, 1)
+			return nil, err
+		}
+		atomic.AddUint64(&api.stats.retries, 1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			atomic.AddUint64(&api.stats.failures, 1)
+			return nil, fmt.Errorf("retry aborted: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// acquire waits for the rate limiter and counts the call in the daily quota
+// the quota is checked first so that a refused call doesn't wait, it is given back when the wait fails
+func (api *API) acquire(ctx context.Context) error {
+	if api.quota != nil {
+		if err := api.quota.Acquire(isLowPriority(ctx)); err != nil {
+			return err
+		}
+	}
+	if api.limiter != nil {
+		if err := api.limiter.Wait(ctx); err != nil {
+			if api.quota != nil {
+				api.quota.release()
+			}
+			return fmt.Errorf("wait for rate limiter: %w", err)
+		}
+	}
+	return nil
+}
+
+// doGet does a single attempt of get
+func (api *API) doGet(ctx context.Context, path string, query url.Values) (*marvelAPIResult, error) {
+	if api.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.timeout)
+		defer cancel()
+	}
+	u := api.buildURL(path, query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request %s error: %w", u.String(), err)
+	}
+	if api.userAgent != "" {
+		req.Header.Set("User-Agent", api.userAgent)
+	}
+	resp, err := api.client().Do(req)
+	if err != nil {
+		return nil, &Error{
+			kind:  ErrUpstreamUnavailable,
+			cause: err,
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body := new(marvelErrorBody)
+		// the error body is informative only, ignore it if it can't be decoded
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBodySize)).Decode(body)
+		e := newResponseError(resp.StatusCode, body)
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, e
+	}
+	apiResult := new(marvelAPIResult)
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(apiResult); err != nil {
+		return nil, malformedError("invalid response: %w", err)
+	}
+	if apiResult.Code != "200" {
+		return nil, newResponseError(resp.StatusCode, &apiResult.marvelErrorBody)
+	}
+	if apiResult.Data == nil {
+		return nil, malformedError("invalid data")
+	}
+	return apiResult, nil
+}
+
+// buildURL builds the marvel api url for path, signed with the api keys
+func (api *API) buildURL(path string, query url.Values) *url.URL {
+	scheme := api.scheme
+	if scheme == "" {
+		scheme = API_SCHEME
+	}
+	ts := time.Now().Unix()
+	hash := md5.Sum([]byte(fmt.Sprintf("%d%s%s", ts, api.apiPrivateKey, api.apiPublicKey)))
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   api.host,
+		Path:   path,
+	}
+	signed := url.Values{}
+	for k, v := range query {
+		signed[k] = v
+	}
+	signed.Set("ts", strconv.FormatInt(ts, 10))
+	signed.Set("apikey", api.apiPublicKey)
+	signed.Set("hash", fmt.Sprintf("%x", hash))
+	u.RawQuery = signed.Encode()
+	return u
+}
+
+// Stats returns the request counters of the api
+func (api *API) Stats() APIStats {
+	return APIStats{
+		Requests: atomic.LoadUint64(&api.stats.requests),
+		Retries:  atomic.LoadUint64(&api.stats.retries),
+		Failures: atomic.LoadUint64(&api.stats.failures),
+	}
+}
+
+// Quota returns the state of the daily quota, ok is false when the quota isn't tracked
+func (api *API) Quota() (status QuotaStatus, ok bool) {
+	if api.quota == nil {
+		return QuotaStatus{}, false
+	}
+	return api.quota.Status(), true
+}
+
+func (api *API) client() *http.Client {
+	if api.httpClient == nil {
+		return http.DefaultClient
 	}
+	return api.httpClient
 }
 
 type apiResult struct {
-	err     error
-	listIDs []int
-	index   int
+	err        error
+	characters []*MarvelCharacter
+	index      int
 }
 
 type marvelAPIResult struct {
-	Code int            `json:"code,omitempty"`
+	marvelErrorBody
 	Data *marvelAPIData `json:"data,omitempty"`
 }
 
 type marvelAPIData struct {
-	Limit   int                `json:"limit,omitempty"`
-	Total   int                `json:"total,omitempty"`
-	Count   int                `json:"count,omitempty"`
-	Results []*MarvelCharacter `json:"results,omitempty"`
+	Offset  int             `json:"offset,omitempty"`
+	Limit   int             `json:"limit,omitempty"`
+	Total   int             `json:"total,omitempty"`
+	Count   int             `json:"count,omitempty"`
+	Results json.RawMessage `json:"results,omitempty"`
+}
+
+// getResults gets path and decodes the results of the response into results
+func (api *API) getResults(ctx context.Context, path string, query url.Values, results interface{}) (*marvelAPIData, error) {
+	apiResult, err := api.get(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResult.Data.Results) > 0 {
+		if err := json.Unmarshal(apiResult.Data.Results, results); err != nil {
+			return nil, malformedError("invalid results: %w", err)
+		}
+	}
+	return apiResult.Data, nil
 }
 
+// MarvelCharacter is the slim character record
 type MarvelCharacter struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
@@ -66,48 +254,73 @@ type MarvelCharacter struct {
 
 // GetCharacterInfo get character info by id
 func (api *API) GetCharacterInfo(id int) (*MarvelCharacter, error) {
-	ts := time.Now().Unix()
-	hash := md5.Sum([]byte(fmt.Sprintf("%d%s%s", ts, api.apiPrivateKey, api.apiPublicKey)))
-	u := url.URL{
-		Scheme: "http",
-		Host:   api.host,
-		Path:   "v1/public/characters/" + strconv.Itoa(id),
-	}
-	query := u.Query()
-	query.Set("ts", strconv.FormatInt(ts, 10))
-	query.Set("apikey", api.apiPublicKey)
-	query.Set("hash", fmt.Sprintf("%x", hash))
-	u.RawQuery = query.Encode()
-	resp, err := http.Get(u.String())
-	if err != nil {
-		return nil, fmt.Errorf("get from %s error: %w", u.String(), err)
+	return api.GetCharacterInfoWithContext(context.Background(), id)
+}
+
+// GetCharacterInfoWithContext get character info by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetCharacterInfoWithContext(ctx context.Context, id int) (*MarvelCharacter, error) {
+	var results []*MarvelCharacter
+	if _, err := api.getResults(ctx, "v1/public/characters/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
-	apiResult := new(marvelAPIResult)
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(apiResult); err != nil {
-		return nil, fmt.Errorf("invalid response: %w", err)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no character found for id %d: %w", id, ErrNotFound)
 	}
-	if apiResult.Code != 200 {
-		return nil, fmt.Errorf("marvel api error, code: %d", apiResult.Code)
+	info := results[0]
+	if info.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, info.ID)
 	}
-	if apiResult.Data == nil {
-		return nil, fmt.Errorf("marvel api error, invalid data")
+	return info, nil
+}
+
+// GetCharacter get the full character record by id
+func (api *API) GetCharacter(id int) (*Character, error) {
+	return api.GetCharacterWithContext(context.Background(), id)
+}
+
+// GetCharacterWithContext get the full character record by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetCharacterWithContext(ctx context.Context, id int) (*Character, error) {
+	var results []*Character
+	if _, err := api.getResults(ctx, "v1/public/characters/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
 	}
-	if len(apiResult.Data.Results) == 0 {
-		return nil, fmt.Errorf("no character found for id %d", id)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no character found for id %d: %w", id, ErrNotFound)
 	}
-	info := apiResult.Data.Results[0]
-	if info.ID != id {
-		return nil, fmt.Errorf("invalid id repsponded: want %d got %d", id, info.ID)
+	character := results[0]
+	if character.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, character.ID)
 	}
-	return info, nil
+	return character, nil
+}
+
+// GetAllCharacters get all characters
+func (api *API) GetAllCharacters() ([]int, error) {
+	return api.GetAllCharactersWithContext(context.Background())
 }
 
-// GetListCharacters get all characters
-func (api *API) GetAllCharacters() (result []int, _ error) {
+// GetAllCharactersWithContext get all characters
+// cancelling ctx aborts every in-flight page request and stops all workers
+func (api *API) GetAllCharactersWithContext(ctx context.Context) ([]int, error) {
+	characters, err := api.GetAllCharacterRecordsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return characterIDs(characters), nil
+}
+
+// GetAllCharacterRecords get the slim records of all characters
+func (api *API) GetAllCharacterRecords() ([]*MarvelCharacter, error) {
+	return api.GetAllCharacterRecordsWithContext(context.Background())
+}
+
+// GetAllCharacterRecordsWithContext get the slim records of all characters
+// cancelling ctx aborts every in-flight page request and stops all workers
+func (api *API) GetAllCharacterRecordsWithContext(parent context.Context) (result []*MarvelCharacter, _ error) {
 	// get first index to determine the total count
-	list, total, err := api.DoGetListCharacters(0, API_LIMIT)
+	list, total, err := api.doGetCharacterPage(parent, 0, API_LIMIT)
 	if err != nil {
 		return nil, fmt.Errorf("get api index 0 error: %w", err)
 	}
@@ -124,7 +337,7 @@ func (api *API) GetAllCharacters() (result []int, _ error) {
 	api.wg.Add(api.concurrentLimit)
 	defer api.wg.Wait()
 	defer close(indexCh)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 	for i := 0; i < api.concurrentLimit; i++ {
 		go api.getCharacterListJob(ctx, indexCh, resultCh)
@@ -138,6 +351,8 @@ func (api *API) GetAllCharacters() (result []int, _ error) {
 	feeder := indexCh
 	for {
 		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("get all characters aborted: %w", ctx.Err())
 		case feeder <- i:
 			i++
 			if i >= num {
@@ -148,7 +363,7 @@ func (api *API) GetAllCharacters() (result []int, _ error) {
 			if apiResult.err != nil {
 				return nil, fmt.Errorf("get api index %d error: %w", apiResult.index, apiResult.err)
 			}
-			result = append(result, apiResult.listIDs...)
+			result = append(result, apiResult.characters...)
 			if len(result) >= total {
 				return
 			}
@@ -161,10 +376,9 @@ func (api *API) GetAllCharacters() (result []int, _ error) {
 
 func (api *API) getCharacterListJob(ctx context.Context, indexCh <-chan int, resultCh chan<- *apiResult) {
 	defer api.wg.Done()
-	result := new(apiResult)
 	for i := range indexCh {
-		result.index = i
-		list, _, err := api.DoGetListCharacters(i, API_LIMIT)
+		result := &apiResult{index: i}
+		list, _, err := api.doGetCharacterPage(ctx, i, API_LIMIT)
 		if err != nil {
 			result.err = err
 			select {
@@ -174,7 +388,7 @@ func (api *API) getCharacterListJob(ctx context.Context, indexCh <-chan int, res
 			}
 			return
 		}
-		result.listIDs = list
+		result.characters = list
 		select {
 		case <-ctx.Done():
 			return
@@ -183,41 +397,39 @@ func (api *API) getCharacterListJob(ctx context.Context, indexCh <-chan int, res
 	}
 }
 
+// DoGetListCharacters get a page of character ids and the total count of characters
 func (api *API) DoGetListCharacters(index int, limit int) ([]int, int, error) {
-	offset := index * limit
-	ts := time.Now().Unix()
-	hash := md5.Sum([]byte(fmt.Sprintf("%d%s%s", ts, api.apiPrivateKey, api.apiPublicKey)))
-	u := url.URL{
-		Scheme: "http",
-		Host:   api.host,
-		Path:   "v1/public/characters",
+	return api.DoGetListCharactersWithContext(context.Background(), index, limit)
+}
+
+// DoGetListCharactersWithContext get a page of character ids and the total count of characters
+// the request is aborted as soon as ctx is done
+func (api *API) DoGetListCharactersWithContext(ctx context.Context, index int, limit int) ([]int, int, error) {
+	characters, total, err := api.doGetCharacterPage(ctx, index, limit)
+	if err != nil {
+		return nil, 0, err
 	}
-	query := u.Query()
-	query.Set("ts", strconv.FormatInt(ts, 10))
-	query.Set("apikey", api.apiPublicKey)
-	query.Set("hash", fmt.Sprintf("%x", hash))
+	return characterIDs(characters), total, nil
+}
+
+// doGetCharacterPage get a page of slim character records and the total count of characters
+func (api *API) doGetCharacterPage(ctx context.Context, index int, limit int) ([]*MarvelCharacter, int, error) {
+	offset := index * limit
+	query := url.Values{}
 	query.Set("limit", strconv.Itoa(limit))
 	query.Set("offset", strconv.Itoa(offset))
-	u.RawQuery = query.Encode()
-	resp, err := http.Get(u.String())
+	var characters []*MarvelCharacter
+	data, err := api.getResults(ctx, "v1/public/characters", query, &characters)
 	if err != nil {
-		return nil, 0, fmt.Errorf("get from %s error: %w", u.String(), err)
+		return nil, 0, err
 	}
-	defer resp.Body.Close()
-	apiResult := new(marvelAPIResult)
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(apiResult); err != nil {
-		return nil, 0, fmt.Errorf("invalid response: %w", err)
-	}
-	if apiResult.Code != 200 {
-		return nil, 0, fmt.Errorf("marvel api error, code: %d", apiResult.Code)
-	}
-	if apiResult.Data == nil {
-		return nil, 0, fmt.Errorf("marvel api error, invalid data")
-	}
-	results := make([]int, 0, apiResult.Data.Count)
-	for _, character := range apiResult.Data.Results {
-		results = append(results, character.ID)
+	return characters, data.Total, nil
+}
+
+func characterIDs(characters []*MarvelCharacter) []int {
+	ids := make([]int, 0, len(characters))
+	for _, character := range characters {
+		ids = append(ids, character.ID)
 	}
-	return results, apiResult.Data.Total, nil
+	return ids
 }
@@ -2,7 +2,10 @@ package marvel
 
 import (
 	"context"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/hauxe/xendit_pratice/test"
 	"github.com/stretchr/testify/require"
@@ -24,7 +27,8 @@ func TestGetCharacterInfo(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler("invalid json"))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		info, err := api.GetCharacterInfo(0)
 		require.Error(t, err)
@@ -35,7 +39,8 @@ func TestGetCharacterInfo(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code": 409}`))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		info, err := api.GetCharacterInfo(0)
 		require.Error(t, err)
@@ -46,7 +51,8 @@ func TestGetCharacterInfo(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code": 200}`))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		info, err := api.GetCharacterInfo(0)
 		require.Error(t, err)
@@ -57,7 +63,8 @@ func TestGetCharacterInfo(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		info, err := api.GetCharacterInfo(0)
 		require.Error(t, err)
@@ -68,7 +75,8 @@ func TestGetCharacterInfo(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		id := 1011334
 		info, err := api.GetCharacterInfo(id)
@@ -77,6 +85,20 @@ func TestGetCharacterInfo(t *testing.T) {
 		require.Equal(t, "3-D Man", info.Name)
 		require.Equal(t, "test description", info.Description)
 	})
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
+		require.NoError(t, err)
+		api := &API{
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		info, err := api.GetCharacterInfoWithContext(ctx, 1011334)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, info)
+	})
 }
 
 func TestGetAllCharacters(t *testing.T) {
@@ -86,7 +108,8 @@ func TestGetAllCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, err := api.GetAllCharacters()
 		require.NoError(t, err)
@@ -98,7 +121,8 @@ func TestGetAllCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, err := api.GetAllCharacters()
 		require.NoError(t, err)
@@ -109,12 +133,27 @@ func TestGetAllCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockFlakyHandler(test.SampleAllData, 100))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, err := api.GetAllCharacters()
 		require.Error(t, err)
 		require.Empty(t, list)
 	})
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData))
+		require.NoError(t, err)
+		api := &API{
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		list, err := api.GetAllCharactersWithContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, list)
+	})
 }
 
 func TestGetCharacterListJob(t *testing.T) {
@@ -133,7 +172,7 @@ func TestGetCharacterListJob(t *testing.T) {
 		indexCh <- 0
 		apiResult := <-resultCh
 		require.Error(t, apiResult.err)
-		require.Empty(t, apiResult.listIDs)
+		require.Empty(t, apiResult.characters)
 		require.Equal(t, 0, apiResult.index)
 		close(indexCh)
 		api.wg.Wait()
@@ -143,7 +182,8 @@ func TestGetCharacterListJob(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		indexCh := make(chan int)
 		resultCh := make(chan *apiResult)
@@ -154,7 +194,7 @@ func TestGetCharacterListJob(t *testing.T) {
 		indexCh <- 0
 		apiResult := <-resultCh
 		require.NoError(t, apiResult.err)
-		require.Len(t, apiResult.listIDs, 1)
+		require.Len(t, apiResult.characters, 1)
 		require.Equal(t, 0, apiResult.index)
 		close(indexCh)
 		api.wg.Wait()
@@ -178,7 +218,8 @@ func TestDoGetListCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler("invalid json"))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, total, err := api.DoGetListCharacters(0, API_LIMIT)
 		require.Error(t, err)
@@ -190,7 +231,8 @@ func TestDoGetListCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code": 409}`))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, total, err := api.DoGetListCharacters(0, API_LIMIT)
 		require.Error(t, err)
@@ -202,7 +244,8 @@ func TestDoGetListCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code": 200}`))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, total, err := api.DoGetListCharacters(0, API_LIMIT)
 		require.Error(t, err)
@@ -214,7 +257,8 @@ func TestDoGetListCharacters(t *testing.T) {
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
 		require.NoError(t, err)
 		api := &API{
-			host: test.GetHost(testServer.URL),
+			scheme: "http",
+			host:   test.GetHost(testServer.URL),
 		}
 		list, total, err := api.DoGetListCharacters(0, API_LIMIT)
 		require.NoError(t, err)
@@ -222,3 +266,116 @@ func TestDoGetListCharacters(t *testing.T) {
 		require.Equal(t, 1, total)
 	})
 }
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("", "public", "private")
+		require.Equal(t, API_HOST, api.host)
+		require.Nil(t, api.httpClient)
+		u := api.buildURL("v1/public/characters", nil)
+		require.Equal(t, "https", u.Scheme)
+		require.Equal(t, "public", u.Query().Get("apikey"))
+	})
+	t.Run("fake_transport", func(t *testing.T) {
+		t.Parallel()
+		var (
+			userAgent string
+			scheme    string
+		)
+		handler := test.NewMockHandler(test.SampleJsonFromMarvel)
+		transport := test.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			userAgent = r.UserAgent()
+			scheme = r.URL.Scheme
+			return test.NewMockTransport(handler).RoundTrip(r)
+		})
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(transport),
+			WithUserAgent("marvel-test-agent"),
+		)
+		info, err := api.GetCharacterInfo(1011334)
+		require.NoError(t, err)
+		require.Equal(t, 1011334, info.ID)
+		require.Equal(t, "marvel-test-agent", userAgent)
+		require.Equal(t, API_SCHEME, scheme)
+	})
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		transport := test.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		})
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(transport),
+			WithTimeout(10*time.Millisecond),
+		)
+		info, err := api.GetCharacterInfo(1011334)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Nil(t, info)
+	})
+	t.Run("custom_client_with_proxy", func(t *testing.T) {
+		t.Parallel()
+		client := &http.Client{Timeout: time.Second}
+		proxy, err := url.Parse("http://proxy.local:3128")
+		require.NoError(t, err)
+		api := NewAPI("", "", "", WithHTTPClient(client), WithProxy(proxy))
+		require.NotSame(t, client, api.httpClient)
+		require.Nil(t, client.Transport)
+		require.Equal(t, time.Second, api.httpClient.Timeout)
+		transport, ok := api.httpClient.Transport.(*http.Transport)
+		require.True(t, ok)
+		req, err := http.NewRequest(http.MethodGet, "https://"+API_HOST, nil)
+		require.NoError(t, err)
+		proxyURL, err := transport.Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, proxy.String(), proxyURL.String())
+	})
+}
+
+func TestGetCharacter(t *testing.T) {
+	t.Parallel()
+	t.Run("not_found", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(`{"code":200,"data":{"results":[]}}`))),
+		)
+		character, err := api.GetCharacter(1011334)
+		require.ErrorIs(t, err, ErrNotFound)
+		require.Nil(t, character)
+	})
+	t.Run("mismatch_id", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))),
+		)
+		character, err := api.GetCharacter(0)
+		require.ErrorIs(t, err, ErrMalformedResponse)
+		require.Nil(t, character)
+	})
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))),
+		)
+		id := 1011334
+		character, err := api.GetCharacter(id)
+		require.NoError(t, err)
+		require.Equal(t, id, character.ID)
+		require.Equal(t, "3-D Man", character.Name)
+		require.Equal(t, "test description", character.Description)
+		require.Equal(t, "2014-04-29T14:18:17-0400", character.Modified.Format(TimeLayout))
+		require.Equal(t, "http://i.annihil.us/u/prod/marvel/i/mg/c/e0/535fecbbb9784.jpg", character.Thumbnail.URL(""))
+		require.Equal(t, "http://gateway.marvel.com/v1/public/characters/1011334", character.ResourceURI)
+		require.Equal(t, 12, character.Comics.Available)
+		require.Len(t, character.Comics.Items, 12)
+		require.Equal(t, 3, character.Series.Available)
+		require.Equal(t, 21, character.Stories.Available)
+		require.Len(t, character.Stories.Items, 20)
+		require.Equal(t, "cover", character.Stories.Items[0].Type)
+		require.Equal(t, "Secret Invasion", character.Events.Items[0].Name)
+		require.Len(t, character.URLs, 3)
+		require.Equal(t, "detail", character.URLs[0].Type)
+		require.Equal(t, &MarvelCharacter{ID: id, Name: "3-D Man", Description: "test description"}, character.Slim())
+	})
+}
@@ -0,0 +1,55 @@
+package marvel
+
+import (
+	"context"
+	"net/url"
+)
+
+// CharacterList is a page of characters
+type CharacterList struct {
+	Page
+	Results []*Character `json:"results"`
+}
+
+// CharacterFilter are the marvel filters of a character list, zero values are ignored
+type CharacterFilter struct {
+	ListOptions
+	// Name matches the full character name, case insensitive
+	Name string
+	// NameStartsWith matches the beginning of the character name, case insensitive
+	NameStartsWith string
+	Comics         []int
+	Series         []int
+	Events         []int
+	Stories        []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f CharacterFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setString(query, "name", f.Name)
+	setString(query, "nameStartsWith", f.NameStartsWith)
+	setInts(query, "comics", f.Comics)
+	setInts(query, "series", f.Series)
+	setInts(query, "events", f.Events)
+	setInts(query, "stories", f.Stories)
+	return query
+}
+
+// ListCharacters get a page of characters matching filter
+func (api *API) ListCharacters(filter CharacterFilter) (*CharacterList, error) {
+	return api.ListCharactersWithContext(context.Background(), filter)
+}
+
+// ListCharactersWithContext get a page of characters matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCharactersWithContext(ctx context.Context, filter CharacterFilter) (*CharacterList, error) {
+	list := new(CharacterList)
+	data, err := api.getResults(ctx, "v1/public/characters", filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
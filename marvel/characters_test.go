@@ -0,0 +1,52 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCharacters(t *testing.T) {
+	t.Parallel()
+	t.Run("name_starts_with", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleJsonFromMarvel, &req)))
+		list, err := api.ListCharactersWithContext(context.Background(), CharacterFilter{
+			ListOptions:    ListOptions{Limit: 5},
+			NameStartsWith: "3-D",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/characters", req.URL.Path)
+		require.Equal(t, "3-D", req.URL.Query().Get("nameStartsWith"))
+		require.Equal(t, "5", req.URL.Query().Get("limit"))
+		require.Empty(t, req.URL.Query().Get("name"))
+		require.Equal(t, 1, list.Total)
+		require.Equal(t, "3-D Man", list.Results[0].Name)
+	})
+	t.Run("name", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleJsonFromMarvel, &req)))
+		_, err := api.ListCharactersWithContext(context.Background(), CharacterFilter{Name: "3-D Man"})
+		require.NoError(t, err)
+		require.Equal(t, "3-D Man", req.URL.Query().Get("name"))
+	})
+}
+
+func TestGetAllCharacterRecords(t *testing.T) {
+	t.Parallel()
+	api := NewAPI("marvel.test", "", "",
+		WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleAllData1stCall))),
+	)
+	list, err := api.GetAllCharacterRecords()
+	require.NoError(t, err)
+	require.Equal(t, []*MarvelCharacter{
+		{ID: 1011334, Name: "1-D Man", Description: "test description"},
+		{ID: 1011335, Name: "2-D Man"},
+		{ID: 1011336, Name: "3-D Man"},
+	}, list)
+}
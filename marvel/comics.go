@@ -0,0 +1,168 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Comic is the full comic record
+type Comic struct {
+	ID                 int               `json:"id"`
+	DigitalID          int               `json:"digitalId"`
+	Title              string            `json:"title"`
+	IssueNumber        float64           `json:"issueNumber"`
+	VariantDescription string            `json:"variantDescription"`
+	Description        string            `json:"description"`
+	Modified           Time              `json:"modified"`
+	ISBN               string            `json:"isbn"`
+	UPC                string            `json:"upc"`
+	DiamondCode        string            `json:"diamondCode"`
+	EAN                string            `json:"ean"`
+	ISSN               string            `json:"issn"`
+	Format             string            `json:"format"`
+	PageCount          int               `json:"pageCount"`
+	TextObjects        []TextObject      `json:"textObjects"`
+	ResourceURI        string            `json:"resourceURI"`
+	URLs               []URL             `json:"urls"`
+	Series             ResourceSummary   `json:"series"`
+	Variants           []ResourceSummary `json:"variants"`
+	Collections        []ResourceSummary `json:"collections"`
+	CollectedIssues    []ResourceSummary `json:"collectedIssues"`
+	Dates              []ComicDate       `json:"dates"`
+	Prices             []ComicPrice      `json:"prices"`
+	Thumbnail          Image             `json:"thumbnail"`
+	Images             []Image           `json:"images"`
+	Creators           ResourceList      `json:"creators"`
+	Characters         ResourceList      `json:"characters"`
+	Stories            ResourceList      `json:"stories"`
+	Events             ResourceList      `json:"events"`
+}
+
+// TextObject is a descriptive text of a comic
+type TextObject struct {
+	Type     string `json:"type"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// ComicDate is a key date of a comic (e.g. onsaleDate, focDate)
+type ComicDate struct {
+	Type string `json:"type"`
+	Date Time   `json:"date"`
+}
+
+// ComicPrice is a price of a comic (e.g. printPrice, digitalPurchasePrice)
+type ComicPrice struct {
+	Type  string  `json:"type"`
+	Price float64 `json:"price"`
+}
+
+// ComicList is a page of comics
+type ComicList struct {
+	Page
+	Results []*Comic `json:"results"`
+}
+
+// ComicFilter are the marvel filters of a comic list, zero values are ignored
+type ComicFilter struct {
+	ListOptions
+	// Format is one of comic, magazine, trade paperback, hardcover, digest, graphic novel, digital comic, infinite comic
+	Format string
+	// FormatType is either comic or collection
+	FormatType string
+	NoVariants bool
+	// DateDescriptor is one of lastWeek, thisWeek, nextWeek, thisMonth
+	DateDescriptor string
+	// DateFrom and DateTo are the bounds of the dateRange filter, both must be set
+	DateFrom        time.Time
+	DateTo          time.Time
+	Title           string
+	TitleStartsWith string
+	StartYear       int
+	IssueNumber     int
+	Characters      []int
+	Creators        []int
+	Series          []int
+	Events          []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f ComicFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setString(query, "format", f.Format)
+	setString(query, "formatType", f.FormatType)
+	if f.NoVariants {
+		query.Set("noVariants", "true")
+	}
+	setString(query, "dateDescriptor", f.DateDescriptor)
+	if !f.DateFrom.IsZero() && !f.DateTo.IsZero() {
+		query.Set("dateRange", f.DateFrom.Format("2006-01-02")+","+f.DateTo.Format("2006-01-02"))
+	}
+	setString(query, "title", f.Title)
+	setString(query, "titleStartsWith", f.TitleStartsWith)
+	setInt(query, "startYear", f.StartYear)
+	setInt(query, "issueNumber", f.IssueNumber)
+	setInts(query, "characters", f.Characters)
+	setInts(query, "creators", f.Creators)
+	setInts(query, "series", f.Series)
+	setInts(query, "events", f.Events)
+	return query
+}
+
+// GetComic get the comic by id
+func (api *API) GetComic(id int) (*Comic, error) {
+	return api.GetComicWithContext(context.Background(), id)
+}
+
+// GetComicWithContext get the comic by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetComicWithContext(ctx context.Context, id int) (*Comic, error) {
+	var results []*Comic
+	if _, err := api.getResults(ctx, "v1/public/comics/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no comic found for id %d: %w", id, ErrNotFound)
+	}
+	comic := results[0]
+	if comic.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, comic.ID)
+	}
+	return comic, nil
+}
+
+// ListComics get a page of comics matching filter
+func (api *API) ListComics(filter ComicFilter) (*ComicList, error) {
+	return api.ListComicsWithContext(context.Background(), filter)
+}
+
+// ListComicsWithContext get a page of comics matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListComicsWithContext(ctx context.Context, filter ComicFilter) (*ComicList, error) {
+	return api.listComics(ctx, "v1/public/comics", filter)
+}
+
+// ListCharacterComics get a page of comics featuring the character matching filter
+func (api *API) ListCharacterComics(characterID int, filter ComicFilter) (*ComicList, error) {
+	return api.ListCharacterComicsWithContext(context.Background(), characterID, filter)
+}
+
+// ListCharacterComicsWithContext get a page of comics featuring the character matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCharacterComicsWithContext(ctx context.Context, characterID int, filter ComicFilter) (*ComicList, error) {
+	return api.listComics(ctx, "v1/public/characters/"+strconv.Itoa(characterID)+"/comics", filter)
+}
+
+func (api *API) listComics(ctx context.Context, path string, filter ComicFilter) (*ComicList, error) {
+	list := new(ComicList)
+	data, err := api.getResults(ctx, path, filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
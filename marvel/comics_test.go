@@ -0,0 +1,100 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingTransport serves json and records the last request
+func newRecordingTransport(json string, last **http.Request) http.RoundTripper {
+	transport := test.NewMockTransport(test.NewMockHandler(json))
+	return test.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		*last = r
+		return transport.RoundTrip(r)
+	})
+}
+
+func TestGetComic(t *testing.T) {
+	t.Parallel()
+	t.Run("not_found", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(`{"code":404,"status":"We couldn't find that comic_issue"}`))),
+		)
+		comic, err := api.GetComicWithContext(context.Background(), 1)
+		require.ErrorIs(t, err, ErrNotFound)
+		require.Nil(t, comic)
+	})
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleComicJson, &req)))
+		comic, err := api.GetComicWithContext(context.Background(), 21366)
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/comics/21366", req.URL.Path)
+		require.Equal(t, 21366, comic.ID)
+		require.Equal(t, "Avengers: The Initiative (2007) #14", comic.Title)
+		require.Equal(t, float64(14), comic.IssueNumber)
+		require.Equal(t, "Comic", comic.Format)
+		require.Equal(t, "Avengers: The Initiative (2007 - 2010)", comic.Series.Name)
+		require.Equal(t, 2.99, comic.Prices[0].Price)
+		require.Equal(t, 2008, comic.Dates[0].Date.Year())
+		require.Equal(t, "writer", comic.Creators.Items[0].Role)
+		require.Len(t, comic.Characters.Items, 2)
+	})
+}
+
+func TestListComics(t *testing.T) {
+	t.Parallel()
+	t.Run("filters", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleComicJson, &req)))
+		list, err := api.ListComicsWithContext(context.Background(), ComicFilter{
+			ListOptions: ListOptions{
+				Limit:  10,
+				Offset: 20,
+			},
+			Format:          "comic",
+			DateFrom:        time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC),
+			DateTo:          time.Date(2008, 12, 31, 0, 0, 0, 0, time.UTC),
+			TitleStartsWith: "Avengers",
+			IssueNumber:     14,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/comics", req.URL.Path)
+		query := req.URL.Query()
+		require.Equal(t, "10", query.Get("limit"))
+		require.Equal(t, "20", query.Get("offset"))
+		require.Equal(t, "comic", query.Get("format"))
+		require.Equal(t, "2008-01-01,2008-12-31", query.Get("dateRange"))
+		require.Equal(t, "Avengers", query.Get("titleStartsWith"))
+		require.Equal(t, "14", query.Get("issueNumber"))
+		require.Empty(t, query.Get("formatType"))
+		require.Equal(t, 1, list.Total)
+		require.Len(t, list.Results, 1)
+	})
+	t.Run("character_comics", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleComicJson, &req)))
+		list, err := api.ListCharacterComicsWithContext(context.Background(), 1011334, ComicFilter{})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/characters/1011334/comics", req.URL.Path)
+		require.Equal(t, 21366, list.Results[0].ID)
+	})
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(`{"code":409,"status":"Limit greater than 100."}`))),
+		)
+		list, err := api.ListComicsWithContext(context.Background(), ComicFilter{ListOptions: ListOptions{Limit: 1000}})
+		require.ErrorIs(t, err, ErrInvalidParameter)
+		require.Nil(t, list)
+	})
+}
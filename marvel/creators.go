@@ -0,0 +1,118 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Creator is the full creator record, creators are writers, artists, editors...
+type Creator struct {
+	ID          int          `json:"id"`
+	FirstName   string       `json:"firstName"`
+	MiddleName  string       `json:"middleName"`
+	LastName    string       `json:"lastName"`
+	Suffix      string       `json:"suffix"`
+	FullName    string       `json:"fullName"`
+	Modified    Time         `json:"modified"`
+	ResourceURI string       `json:"resourceURI"`
+	URLs        []URL        `json:"urls"`
+	Thumbnail   Image        `json:"thumbnail"`
+	Series      ResourceList `json:"series"`
+	Stories     ResourceList `json:"stories"`
+	Comics      ResourceList `json:"comics"`
+	Events      ResourceList `json:"events"`
+}
+
+// CreatorList is a page of creators
+type CreatorList struct {
+	Page
+	Results []*Creator `json:"results"`
+}
+
+// CreatorFilter are the marvel filters of a creator list, zero values are ignored
+type CreatorFilter struct {
+	ListOptions
+	FirstName            string
+	MiddleName           string
+	LastName             string
+	Suffix               string
+	NameStartsWith       string
+	FirstNameStartsWith  string
+	MiddleNameStartsWith string
+	LastNameStartsWith   string
+	Comics               []int
+	Series               []int
+	Events               []int
+	Stories              []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f CreatorFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setString(query, "firstName", f.FirstName)
+	setString(query, "middleName", f.MiddleName)
+	setString(query, "lastName", f.LastName)
+	setString(query, "suffix", f.Suffix)
+	setString(query, "nameStartsWith", f.NameStartsWith)
+	setString(query, "firstNameStartsWith", f.FirstNameStartsWith)
+	setString(query, "middleNameStartsWith", f.MiddleNameStartsWith)
+	setString(query, "lastNameStartsWith", f.LastNameStartsWith)
+	setInts(query, "comics", f.Comics)
+	setInts(query, "series", f.Series)
+	setInts(query, "events", f.Events)
+	setInts(query, "stories", f.Stories)
+	return query
+}
+
+// GetCreator get the creator by id
+func (api *API) GetCreator(id int) (*Creator, error) {
+	return api.GetCreatorWithContext(context.Background(), id)
+}
+
+// GetCreatorWithContext get the creator by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetCreatorWithContext(ctx context.Context, id int) (*Creator, error) {
+	var results []*Creator
+	if _, err := api.getResults(ctx, "v1/public/creators/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no creator found for id %d: %w", id, ErrNotFound)
+	}
+	creator := results[0]
+	if creator.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, creator.ID)
+	}
+	return creator, nil
+}
+
+// ListCreators get a page of creators matching filter
+func (api *API) ListCreators(filter CreatorFilter) (*CreatorList, error) {
+	return api.ListCreatorsWithContext(context.Background(), filter)
+}
+
+// ListCreatorsWithContext get a page of creators matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCreatorsWithContext(ctx context.Context, filter CreatorFilter) (*CreatorList, error) {
+	list := new(CreatorList)
+	data, err := api.getResults(ctx, "v1/public/creators", filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
+
+// ListCreatorComics get a page of comics the creator worked on matching filter
+func (api *API) ListCreatorComics(creatorID int, filter ComicFilter) (*ComicList, error) {
+	return api.ListCreatorComicsWithContext(context.Background(), creatorID, filter)
+}
+
+// ListCreatorComicsWithContext get a page of comics the creator worked on matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCreatorComicsWithContext(ctx context.Context, creatorID int, filter ComicFilter) (*ComicList, error) {
+	return api.listComics(ctx, "v1/public/creators/"+strconv.Itoa(creatorID)+"/comics", filter)
+}
@@ -0,0 +1,53 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreators(t *testing.T) {
+	t.Parallel()
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleCreatorJson, &req)))
+		creator, err := api.GetCreatorWithContext(context.Background(), 1133)
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/creators/1133", req.URL.Path)
+		require.Equal(t, "Dan Slott", creator.FullName)
+		require.Equal(t, 1, creator.Comics.Available)
+	})
+	t.Run("get_not_found", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(`{"code":404,"status":"We couldn't find that creator"}`))),
+		)
+		creator, err := api.GetCreatorWithContext(context.Background(), 1)
+		require.ErrorIs(t, err, ErrNotFound)
+		require.Nil(t, creator)
+	})
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleCreatorJson, &req)))
+		list, err := api.ListCreatorsWithContext(context.Background(), CreatorFilter{LastNameStartsWith: "Slo"})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/creators", req.URL.Path)
+		require.Equal(t, "Slo", req.URL.Query().Get("lastNameStartsWith"))
+		require.Len(t, list.Results, 1)
+	})
+	t.Run("creator_comics", func(t *testing.T) {
+		t.Parallel()
+		var req *http.Request
+		api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(test.SampleComicJson, &req)))
+		list, err := api.ListCreatorComicsWithContext(context.Background(), 1133, ComicFilter{ListOptions: ListOptions{Limit: 100}})
+		require.NoError(t, err)
+		require.Equal(t, "/v1/public/creators/1133/comics", req.URL.Path)
+		require.Equal(t, "100", req.URL.Query().Get("limit"))
+		require.Equal(t, 21366, list.Results[0].ID)
+	})
+}
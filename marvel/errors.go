@@ -0,0 +1,141 @@
+package marvel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors returned by the api, use errors.Is to check the kind of a failure
+var (
+	ErrNotFound            = errors.New("marvel api: not found")
+	ErrUnauthorized        = errors.New("marvel api: unauthorized")
+	ErrRateLimited         = errors.New("marvel api: rate limited")
+	ErrInvalidParameter    = errors.New("marvel api: invalid parameter")
+	ErrUpstreamUnavailable = errors.New("marvel api: upstream unavailable")
+	ErrMalformedResponse   = errors.New("marvel api: malformed response")
+)
+
+// Error is a failure of a request to marvel api
+// it matches one of the Err* kinds with errors.Is
+type Error struct {
+	// StatusCode is the http status responded by marvel, zero if no response was received
+	StatusCode int
+	// Code is the marvel error code, either numeric ("409") or textual ("InvalidCredentials")
+	Code string
+	// Message is the marvel error message
+	Message string
+	// RetryAfter is the delay requested by marvel before retrying
+	RetryAfter time.Duration
+
+	kind  error
+	cause error
+}
+
+func (e *Error) Error() string {
+	msg := e.kind.Error()
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(", status: %d", e.StatusCode)
+	}
+	if e.Code != "" {
+		msg += ", code: " + e.Code
+	}
+	if e.Message != "" {
+		msg += ", message: " + e.Message
+	}
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Is reports whether the error is of kind target
+func (e *Error) Is(target error) bool {
+	return e.kind == target
+}
+
+// Unwrap returns the underlying cause of the error
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// apiCode is the code field of marvel responses, marvel sends it either as a number or a string
+type apiCode string
+
+func (c *apiCode) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*c = apiCode(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("invalid code %s: %w", string(b), err)
+	}
+	*c = apiCode(n.String())
+	return nil
+}
+
+// marvelErrorBody is the body marvel responds on error
+type marvelErrorBody struct {
+	Code    apiCode `json:"code"`
+	Status  string  `json:"status"`
+	Message string  `json:"message"`
+}
+
+// newResponseError builds the error of a marvel error response
+func newResponseError(statusCode int, body *marvelErrorBody) *Error {
+	e := &Error{
+		StatusCode: statusCode,
+		Code:       string(body.Code),
+		Message:    body.Message,
+	}
+	if e.Message == "" {
+		e.Message = body.Status
+	}
+	// marvel may answer with http 200 and the actual status in the code field
+	status := statusCode
+	if status == http.StatusOK {
+		if code, err := strconv.Atoi(e.Code); err == nil {
+			status = code
+		}
+	}
+	e.kind = errorKind(status, e.Code)
+	return e
+}
+
+// errorKind classifies a marvel failure by its http status and marvel code
+func errorKind(status int, code string) error {
+	switch code {
+	case "InvalidCredentials", "InvalidReferer", "InvalidHash", "MissingAPIKey", "MissingHash", "MissingTimestamp":
+		return ErrUnauthorized
+	case "RequestThrottled":
+		return ErrRateLimited
+	case "MethodNotAllowed":
+		return ErrInvalidParameter
+	}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusBadRequest || status == http.StatusConflict:
+		return ErrInvalidParameter
+	case status >= http.StatusInternalServerError:
+		return ErrUpstreamUnavailable
+	default:
+		return ErrMalformedResponse
+	}
+}
+
+func malformedError(format string, args ...interface{}) *Error {
+	return &Error{
+		kind:  ErrMalformedResponse,
+		cause: fmt.Errorf(format, args...),
+	}
+}
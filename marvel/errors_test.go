@@ -0,0 +1,129 @@
+package marvel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+// newErrorTransport responds every request with status and body
+func newErrorTransport(status int, body string) http.RoundTripper {
+	return test.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteHeader(status)
+		_, _ = rec.WriteString(body)
+		resp := rec.Result()
+		resp.Request = r
+		return resp, nil
+	})
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		code    string
+		message string
+	}{
+		{
+			name:    "invalid_credentials",
+			status:  http.StatusUnauthorized,
+			body:    `{"code":"InvalidCredentials","message":"The passed API key is invalid."}`,
+			kind:    ErrUnauthorized,
+			code:    "InvalidCredentials",
+			message: "The passed API key is invalid.",
+		},
+		{
+			name:    "not_found",
+			status:  http.StatusNotFound,
+			body:    `{"code":404,"status":"We couldn't find that character"}`,
+			kind:    ErrNotFound,
+			code:    "404",
+			message: "We couldn't find that character",
+		},
+		{
+			name:    "throttled",
+			status:  http.StatusTooManyRequests,
+			body:    `{"code":"RequestThrottled","message":"You have exceeded your rate limit."}`,
+			kind:    ErrRateLimited,
+			code:    "RequestThrottled",
+			message: "You have exceeded your rate limit.",
+		},
+		{
+			name:    "invalid_parameter_in_body",
+			status:  http.StatusOK,
+			body:    `{"code":409,"status":"Limit greater than 100."}`,
+			kind:    ErrInvalidParameter,
+			code:    "409",
+			message: "Limit greater than 100.",
+		},
+		{
+			name:   "upstream_error_without_body",
+			status: http.StatusBadGateway,
+			body:   "<html>bad gateway</html>",
+			kind:   ErrUpstreamUnavailable,
+		},
+		{
+			name:   "malformed",
+			status: http.StatusOK,
+			body:   "invalid json",
+			kind:   ErrMalformedResponse,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			api := NewAPI("marvel.test", "", "",
+				WithTransport(newErrorTransport(c.status, c.body)),
+				WithRetryPolicy(RetryPolicy{}),
+			)
+			info, err := api.GetCharacterInfo(1011334)
+			require.Nil(t, info)
+			require.ErrorIs(t, err, c.kind)
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, c.code, apiErr.Code)
+			require.Equal(t, c.message, apiErr.Message)
+		})
+	}
+	t.Run("network_error", func(t *testing.T) {
+		t.Parallel()
+		api := &API{
+			host: "test_failed_host",
+		}
+		_, _, err := api.DoGetListCharacters(0, API_LIMIT)
+		require.ErrorIs(t, err, ErrUpstreamUnavailable)
+	})
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := api.GetCharacterInfoWithContext(ctx, 1011334)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+	t.Run("no_character", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newErrorTransport(http.StatusOK, `{"code":200,"data":{"results":[]}}`)),
+		)
+		_, err := api.GetCharacterInfo(1)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("quota_exceeded", func(t *testing.T) {
+		t.Parallel()
+		require.ErrorIs(t, ErrQuotaExceeded, ErrRateLimited)
+	})
+}
@@ -0,0 +1,114 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Event is the full event record, events are big crossover storylines
+type Event struct {
+	ID          int              `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	ResourceURI string           `json:"resourceURI"`
+	URLs        []URL            `json:"urls"`
+	Modified    Time             `json:"modified"`
+	Start       Time             `json:"start"`
+	End         Time             `json:"end"`
+	Thumbnail   Image            `json:"thumbnail"`
+	Creators    ResourceList     `json:"creators"`
+	Characters  ResourceList     `json:"characters"`
+	Stories     ResourceList     `json:"stories"`
+	Comics      ResourceList     `json:"comics"`
+	Series      ResourceList     `json:"series"`
+	Next        *ResourceSummary `json:"next"`
+	Previous    *ResourceSummary `json:"previous"`
+}
+
+// EventList is a page of events
+type EventList struct {
+	Page
+	Results []*Event `json:"results"`
+}
+
+// EventFilter are the marvel filters of an event list, zero values are ignored
+type EventFilter struct {
+	ListOptions
+	Name           string
+	NameStartsWith string
+	Creators       []int
+	Characters     []int
+	Series         []int
+	Comics         []int
+	Stories        []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f EventFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setString(query, "name", f.Name)
+	setString(query, "nameStartsWith", f.NameStartsWith)
+	setInts(query, "creators", f.Creators)
+	setInts(query, "characters", f.Characters)
+	setInts(query, "series", f.Series)
+	setInts(query, "comics", f.Comics)
+	setInts(query, "stories", f.Stories)
+	return query
+}
+
+// GetEvent get the event by id
+func (api *API) GetEvent(id int) (*Event, error) {
+	return api.GetEventWithContext(context.Background(), id)
+}
+
+// GetEventWithContext get the event by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetEventWithContext(ctx context.Context, id int) (*Event, error) {
+	var results []*Event
+	if _, err := api.getResults(ctx, "v1/public/events/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no event found for id %d: %w", id, ErrNotFound)
+	}
+	event := results[0]
+	if event.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, event.ID)
+	}
+	return event, nil
+}
+
+// ListEvents get a page of events matching filter
+func (api *API) ListEvents(filter EventFilter) (*EventList, error) {
+	return api.ListEventsWithContext(context.Background(), filter)
+}
+
+// ListEventsWithContext get a page of events matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListEventsWithContext(ctx context.Context, filter EventFilter) (*EventList, error) {
+	return api.listEvents(ctx, "v1/public/events", filter)
+}
+
+// ListCharacterEvents get a page of events the character takes part in matching filter
+func (api *API) ListCharacterEvents(characterID int, filter EventFilter) (*EventList, error) {
+	return api.ListCharacterEventsWithContext(context.Background(), characterID, filter)
+}
+
+// ListCharacterEventsWithContext get a page of events the character takes part in matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCharacterEventsWithContext(ctx context.Context, characterID int, filter EventFilter) (*EventList, error) {
+	return api.listEvents(ctx, "v1/public/characters/"+strconv.Itoa(characterID)+"/events", filter)
+}
+
+func (api *API) listEvents(ctx context.Context, path string, filter EventFilter) (*EventList, error) {
+	list := new(EventList)
+	data, err := api.getResults(ctx, path, filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
@@ -0,0 +1,188 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when the daily quota of the api key doesn't allow more calls
+// it is a kind of ErrRateLimited
+var ErrQuotaExceeded = fmt.Errorf("daily quota exceeded: %w", ErrRateLimited)
+
+// WithRateLimit throttles the requests sent to marvel api to rps requests per second
+// with bursts up to burst requests, requests wait for their turn
+func WithRateLimit(rps float64, burst int) Option {
+	return func(api *API) {
+		api.limiter = newRateLimiter(rps, burst)
+	}
+}
+
+// WithDailyQuota tracks the calls sent to marvel api per UTC day against limit
+// when the remaining calls drop to reserve, low priority requests are refused
+// and once limit is reached every request is refused with ErrQuotaExceeded
+func WithDailyQuota(limit, reserve int) Option {
+	return func(api *API) {
+		api.quota = NewQuotaTracker(limit, reserve)
+	}
+}
+
+// WithQuotaTracker shares tracker between several api objects using the same api key
+func WithQuotaTracker(tracker *QuotaTracker) Option {
+	return func(api *API) {
+		api.quota = tracker
+	}
+}
+
+type lowPriorityKey struct{}
+
+// WithLowPriority marks the requests made with ctx as low priority
+// low priority requests are refused once the daily quota is near its limit
+// so the remaining budget is kept for user facing requests
+func WithLowPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lowPriorityKey{}, true)
+}
+
+func isLowPriority(ctx context.Context) bool {
+	low, _ := ctx.Value(lowPriorityKey{}).(bool)
+	return low
+}
+
+// rateLimiter is a token bucket limiter
+type rateLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.lock.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// take the token now even if it is not available yet, the deficit is the time to wait
+	l.tokens--
+	if l.tokens >= 0 {
+		l.lock.Unlock()
+		return nil
+	}
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.lock.Unlock()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// give back the token we won't use
+		l.lock.Lock()
+		l.tokens++
+		l.lock.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// QuotaStatus is the state of the daily quota
+type QuotaStatus struct {
+	Limit     int
+	Used      int
+	Remaining int
+	// NearLimit is true when the remaining calls are kept for high priority requests only
+	NearLimit bool
+	// ResetAt is the time the quota is renewed (next UTC midnight)
+	ResetAt time.Time
+}
+
+// QuotaTracker counts the calls sent to marvel api per UTC day
+type QuotaTracker struct {
+	lock    sync.Mutex
+	limit   int
+	reserve int
+	day     time.Time
+	used    int
+	now     func() time.Time
+}
+
+// NewQuotaTracker creates a tracker allowing limit calls per UTC day
+// the last reserve calls are kept for high priority requests
+func NewQuotaTracker(limit, reserve int) *QuotaTracker {
+	return &QuotaTracker{
+		limit:   limit,
+		reserve: reserve,
+		now:     time.Now,
+	}
+}
+
+// Acquire counts a call, it fails with ErrQuotaExceeded if the quota doesn't allow it
+func (q *QuotaTracker) Acquire(lowPriority bool) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rollover()
+	remaining := q.limit - q.used
+	if remaining <= 0 || (lowPriority && remaining <= q.reserve) {
+		return ErrQuotaExceeded
+	}
+	q.used++
+	return nil
+}
+
+// release gives back a call counted by Acquire which wasn't sent
+func (q *QuotaTracker) release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rollover()
+	// the counter of a new day has nothing to give back
+	if q.used > 0 {
+		q.used--
+	}
+}
+
+// Status returns the current state of the quota
+func (q *QuotaTracker) Status() QuotaStatus {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.rollover()
+	remaining := q.limit - q.used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return QuotaStatus{
+		Limit:     q.limit,
+		Used:      q.used,
+		Remaining: remaining,
+		NearLimit: remaining <= q.reserve,
+		ResetAt:   q.day.AddDate(0, 0, 1),
+	}
+}
+
+// rollover resets the counter when the UTC day changed, lock must be held
+func (q *QuotaTracker) rollover() {
+	now := q.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.Equal(q.day) {
+		q.day = day
+		q.used = 0
+	}
+}
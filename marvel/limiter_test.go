@@ -0,0 +1,109 @@
+package marvel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+	t.Run("burst_then_throttle", func(t *testing.T) {
+		t.Parallel()
+		l := newRateLimiter(100, 2)
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+		// 2 tokens from the burst then 2 tokens at 100 per second
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(15*time.Millisecond))
+	})
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+		l := newRateLimiter(0.1, 1)
+		require.NoError(t, l.Wait(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	})
+}
+
+func TestQuotaTracker(t *testing.T) {
+	t.Parallel()
+	t.Run("reserve_and_limit", func(t *testing.T) {
+		t.Parallel()
+		q := NewQuotaTracker(3, 1)
+		require.NoError(t, q.Acquire(true))
+		require.NoError(t, q.Acquire(false))
+		status := q.Status()
+		require.Equal(t, 1, status.Remaining)
+		require.True(t, status.NearLimit)
+		require.ErrorIs(t, q.Acquire(true), ErrQuotaExceeded)
+		require.NoError(t, q.Acquire(false))
+		require.ErrorIs(t, q.Acquire(false), ErrQuotaExceeded)
+		require.Equal(t, 3, q.Status().Used)
+	})
+	t.Run("reset_next_utc_day", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 4, 1, 23, 59, 0, 0, time.UTC)
+		q := NewQuotaTracker(1, 0)
+		q.now = func() time.Time { return now }
+		require.NoError(t, q.Acquire(false))
+		require.ErrorIs(t, q.Acquire(false), ErrQuotaExceeded)
+		require.Equal(t, time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC), q.Status().ResetAt)
+		now = now.Add(time.Minute)
+		require.NoError(t, q.Acquire(false))
+	})
+	t.Run("api_refused", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))),
+			WithDailyQuota(2, 1),
+		)
+		_, ok := (&API{}).Quota()
+		require.False(t, ok)
+		_, err := api.GetCharacterInfoWithContext(WithLowPriority(context.Background()), 1011334)
+		require.NoError(t, err)
+		_, err = api.GetCharacterInfoWithContext(WithLowPriority(context.Background()), 1011334)
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		_, err = api.GetCharacterInfo(1011334)
+		require.NoError(t, err)
+		quota, ok := api.Quota()
+		require.True(t, ok)
+		require.Zero(t, quota.Remaining)
+		require.Equal(t, uint64(2), api.Stats().Requests)
+	})
+	t.Run("rate_limit_canceled", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))),
+			WithRateLimit(0.1, 1),
+			WithDailyQuota(2, 0),
+		)
+		_, err := api.GetCharacterInfo(1011334)
+		require.NoError(t, err)
+		// the call which gave up waiting for the rate limiter isn't counted
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = api.GetCharacterInfoWithContext(ctx, 1011334)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		quota, ok := api.Quota()
+		require.True(t, ok)
+		require.Equal(t, 1, quota.Used)
+		require.Equal(t, 1, quota.Remaining)
+		require.Equal(t, uint64(1), api.Stats().Requests)
+	})
+	t.Run("release_new_day", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2021, 4, 1, 23, 59, 0, 0, time.UTC)
+		q := NewQuotaTracker(1, 0)
+		q.now = func() time.Time { return now }
+		require.NoError(t, q.Acquire(false))
+		now = now.Add(time.Minute)
+		q.release()
+		require.Zero(t, q.Status().Used)
+	})
+}
@@ -0,0 +1,190 @@
+package marvel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout of the dates responded by marvel api
+const TimeLayout = "2006-01-02T15:04:05-0700"
+
+// eventTimeLayout is the layout of event start and end dates
+const eventTimeLayout = "2006-01-02 15:04:05"
+
+// Time is a date responded by marvel api
+type Time struct {
+	time.Time
+}
+
+// MarshalJSON encodes the date in marvel layout, zero date is encoded as null
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(TimeLayout))
+}
+
+// UnmarshalJSON decodes a date in marvel layout, RFC3339 or the layout of event dates
+// marvel responds dates such as -0001-11-30T00:00:00-0500 for unknown dates,
+// a date which can't be parsed is decoded as the zero date so that it doesn't fail the whole response
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(TimeLayout, s)
+	if err != nil {
+		parsed, err = time.Parse(time.RFC3339, s)
+	}
+	if err != nil {
+		parsed, err = time.Parse(eventTimeLayout, s)
+	}
+	if err != nil {
+		t.Time = time.Time{}
+		return nil
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Image is an image of a resource
+type Image struct {
+	Path      string `json:"path"`
+	Extension string `json:"extension"`
+}
+
+// URL returns the url of the image in variant (e.g. portrait_xlarge), empty variant is the full size image
+func (i Image) URL(variant string) string {
+	if i.Path == "" {
+		return ""
+	}
+	if variant == "" {
+		return i.Path + "." + i.Extension
+	}
+	return i.Path + "/" + variant + "." + i.Extension
+}
+
+// URL is a public web site url of a resource
+type URL struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ResourceSummary is a reference to a resource
+type ResourceSummary struct {
+	ResourceURI string `json:"resourceURI"`
+	Name        string `json:"name"`
+	// Type is only set for stories and creators (role)
+	Type string `json:"type,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// ID returns the id of the referenced resource parsed from its uri
+func (r ResourceSummary) ID() (int, error) {
+	i := strings.LastIndex(r.ResourceURI, "/")
+	id, err := strconv.Atoi(r.ResourceURI[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid resource uri %s: %w", r.ResourceURI, err)
+	}
+	return id, nil
+}
+
+// ResourceList is a list of references to resources
+// Items holds at most Returned references out of Available
+type ResourceList struct {
+	Available     int               `json:"available"`
+	Returned      int               `json:"returned"`
+	CollectionURI string            `json:"collectionURI"`
+	Items         []ResourceSummary `json:"items"`
+}
+
+// Character is the full character record
+type Character struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Modified    Time         `json:"modified"`
+	ResourceURI string       `json:"resourceURI"`
+	URLs        []URL        `json:"urls"`
+	Thumbnail   Image        `json:"thumbnail"`
+	Comics      ResourceList `json:"comics"`
+	Stories     ResourceList `json:"stories"`
+	Events      ResourceList `json:"events"`
+	Series      ResourceList `json:"series"`
+}
+
+// Slim returns the slim record of the character
+func (c *Character) Slim() *MarvelCharacter {
+	return &MarvelCharacter{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+	}
+}
+
+// ListOptions are the paging and ordering parameters common to every list request
+type ListOptions struct {
+	// Limit is the page size, marvel allows 1 to 100, zero uses the marvel default (20)
+	Limit  int
+	Offset int
+	// OrderBy is a marvel order field, prefixed with "-" for descending order
+	OrderBy       string
+	ModifiedSince time.Time
+}
+
+func (o ListOptions) setValues(query url.Values) {
+	setInt(query, "limit", o.Limit)
+	setInt(query, "offset", o.Offset)
+	setString(query, "orderBy", o.OrderBy)
+	if !o.ModifiedSince.IsZero() {
+		query.Set("modifiedSince", o.ModifiedSince.Format("2006-01-02"))
+	}
+}
+
+// Page is the paging information of a list response
+type Page struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Total  int `json:"total"`
+	Count  int `json:"count"`
+}
+
+func newPage(data *marvelAPIData) Page {
+	return Page{
+		Offset: data.Offset,
+		Limit:  data.Limit,
+		Total:  data.Total,
+		Count:  data.Count,
+	}
+}
+
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setInt(query url.Values, key string, value int) {
+	if value != 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
+
+func setInts(query url.Values, key string, values []int) {
+	if len(values) == 0 {
+		return
+	}
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, strconv.Itoa(v))
+	}
+	query.Set(key, strings.Join(s, ","))
+}
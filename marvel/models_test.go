@@ -0,0 +1,77 @@
+package marvel
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTime(t *testing.T) {
+	t.Parallel()
+	t.Run("marvel_layout", func(t *testing.T) {
+		t.Parallel()
+		var v Time
+		err := json.Unmarshal([]byte(`"2014-04-29T14:18:17-0400"`), &v)
+		require.NoError(t, err)
+		require.True(t, v.Equal(time.Date(2014, 4, 29, 18, 18, 17, 0, time.UTC)))
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.Equal(t, `"2014-04-29T14:18:17-0400"`, string(b))
+	})
+	t.Run("rfc3339", func(t *testing.T) {
+		t.Parallel()
+		var v Time
+		err := json.Unmarshal([]byte(`"2014-04-29T18:18:17Z"`), &v)
+		require.NoError(t, err)
+		require.True(t, v.Equal(time.Date(2014, 4, 29, 18, 18, 17, 0, time.UTC)))
+	})
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		var v Time
+		require.NoError(t, json.Unmarshal([]byte(`""`), &v))
+		require.True(t, v.IsZero())
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.Equal(t, "null", string(b))
+	})
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		for _, s := range []string{`"-0001-11-30T00:00:00-0500"`, `"yesterday"`} {
+			v := Time{time.Now()}
+			require.NoError(t, json.Unmarshal([]byte(s), &v), s)
+			require.True(t, v.IsZero(), s)
+		}
+	})
+	t.Run("not_a_string", func(t *testing.T) {
+		t.Parallel()
+		var v Time
+		require.Error(t, json.Unmarshal([]byte(`20140429`), &v))
+	})
+	t.Run("unknown_in_list", func(t *testing.T) {
+		t.Parallel()
+		var characters []*Character
+		err := json.Unmarshal([]byte(`[{"id":1011334,"modified":"-0001-11-30T00:00:00-0500"},{"id":1017100,"modified":"2013-09-18T15:54:04-0400"}]`), &characters)
+		require.NoError(t, err)
+		require.Len(t, characters, 2)
+		require.True(t, characters[0].Modified.IsZero())
+		require.False(t, characters[1].Modified.IsZero())
+	})
+}
+
+func TestResourceSummaryID(t *testing.T) {
+	t.Parallel()
+	id, err := ResourceSummary{ResourceURI: "http://gateway.marvel.com/v1/public/comics/21366"}.ID()
+	require.NoError(t, err)
+	require.Equal(t, 21366, id)
+	_, err = ResourceSummary{ResourceURI: "http://gateway.marvel.com/v1/public/comics"}.ID()
+	require.Error(t, err)
+}
+
+func TestImageURL(t *testing.T) {
+	t.Parallel()
+	img := Image{Path: "http://i.annihil.us/u/prod/marvel/i/mg/c/e0/535fecbbb9784", Extension: "jpg"}
+	require.Equal(t, "http://i.annihil.us/u/prod/marvel/i/mg/c/e0/535fecbbb9784/portrait_xlarge.jpg", img.URL("portrait_xlarge"))
+	require.Empty(t, Image{}.URL(""))
+}
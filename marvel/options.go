@@ -0,0 +1,83 @@
+package marvel
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures an API object
+type Option func(*API)
+
+// WithHTTPClient uses client to send every request to marvel api
+func WithHTTPClient(client *http.Client) Option {
+	return func(api *API) {
+		api.httpClient = client
+	}
+}
+
+// WithTransport uses rt as the transport of the http client
+// this is useful to plug a fake transport in tests
+func WithTransport(rt http.RoundTripper) Option {
+	return func(api *API) {
+		api.transport = rt
+	}
+}
+
+// WithTimeout limits the duration of every single request to marvel api
+func WithTimeout(timeout time.Duration) Option {
+	return func(api *API) {
+		api.timeout = timeout
+	}
+}
+
+// WithScheme sets the url scheme (http or https) used to reach marvel api
+func WithScheme(scheme string) Option {
+	return func(api *API) {
+		api.scheme = scheme
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(userAgent string) Option {
+	return func(api *API) {
+		api.userAgent = userAgent
+	}
+}
+
+// WithProxy sends every request through the proxy
+// it only applies to *http.Transport, a custom round tripper must handle proxying itself
+func WithProxy(proxy *url.URL) Option {
+	return func(api *API) {
+		api.proxy = proxy
+	}
+}
+
+// buildHTTPClient combines the configured client, transport and proxy into the client used by the api
+func buildHTTPClient(client *http.Client, transport http.RoundTripper, proxy *url.URL) *http.Client {
+	if client == nil && transport == nil && proxy == nil {
+		return nil
+	}
+	if client == nil {
+		client = &http.Client{}
+	} else {
+		// never mutate a client owned by the caller
+		c := *client
+		client = &c
+	}
+	if transport != nil {
+		client.Transport = transport
+	}
+	if proxy != nil {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		if t, ok := base.(*http.Transport); ok {
+			t = t.Clone()
+			t.Proxy = http.ProxyURL(proxy)
+			client.Transport = t
+		}
+	}
+	return client
+}
@@ -0,0 +1,169 @@
+package marvel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cases := []struct {
+		// name is the path of the resource
+		name string
+		json string
+		id   int
+		get  func(api *API, id int) (interface{}, error)
+		// checkGet checks the fields of the resource got by id
+		checkGet func(t *testing.T, v interface{})
+		list     func(api *API) (interface{}, error)
+		// query is the filter sent by list
+		query           url.Values
+		listByCharacter func(api *API, characterID int) (interface{}, error)
+	}{
+		{
+			name: "events",
+			json: test.SampleEventJson,
+			id:   269,
+			get: func(api *API, id int) (interface{}, error) {
+				return api.GetEventWithContext(ctx, id)
+			},
+			checkGet: func(t *testing.T, v interface{}) {
+				event := v.(*Event)
+				require.Equal(t, "Secret Invasion", event.Title)
+				require.Equal(t, 2008, event.Start.Year())
+				require.Equal(t, 2009, event.End.Year())
+				require.Equal(t, "Dark Reign", event.Next.Name)
+				require.Equal(t, "Initiative", event.Previous.Name)
+			},
+			list: func(api *API) (interface{}, error) {
+				return api.ListEventsWithContext(ctx, EventFilter{NameStartsWith: "Secret"})
+			},
+			query: url.Values{"nameStartsWith": {"Secret"}},
+			listByCharacter: func(api *API, characterID int) (interface{}, error) {
+				return api.ListCharacterEventsWithContext(ctx, characterID, EventFilter{})
+			},
+		},
+		{
+			name: "series",
+			json: test.SampleSeriesJson,
+			id:   1945,
+			get: func(api *API, id int) (interface{}, error) {
+				return api.GetSeriesWithContext(ctx, id)
+			},
+			checkGet: func(t *testing.T, v interface{}) {
+				series := v.(*Series)
+				require.Equal(t, "Avengers: The Initiative (2007 - 2010)", series.Title)
+				require.Equal(t, 2007, series.StartYear)
+				require.Equal(t, 2010, series.EndYear)
+				require.Nil(t, series.Next)
+			},
+			list: func(api *API) (interface{}, error) {
+				return api.ListSeriesWithContext(ctx, SeriesFilter{
+					TitleStartsWith: "Avengers",
+					SeriesType:      "ongoing",
+					Characters:      []int{1011334, 1009165},
+				})
+			},
+			query: url.Values{
+				"titleStartsWith": {"Avengers"},
+				"seriesType":      {"ongoing"},
+				"characters":      {"1011334,1009165"},
+			},
+			listByCharacter: func(api *API, characterID int) (interface{}, error) {
+				return api.ListCharacterSeriesWithContext(ctx, characterID, SeriesFilter{})
+			},
+		},
+		{
+			name: "stories",
+			json: test.SampleStoryJson,
+			id:   19947,
+			get: func(api *API, id int) (interface{}, error) {
+				return api.GetStoryWithContext(ctx, id)
+			},
+			checkGet: func(t *testing.T, v interface{}) {
+				story := v.(*Story)
+				require.Equal(t, "cover", story.Type)
+				require.Nil(t, story.Thumbnail)
+				require.Equal(t, "Marvel Premiere (1972) #35", story.OriginalIssue.Name)
+			},
+			list: func(api *API) (interface{}, error) {
+				return api.ListStoriesWithContext(ctx, StoryFilter{Comics: []int{10223}})
+			},
+			query: url.Values{"comics": {"10223"}},
+			listByCharacter: func(api *API, characterID int) (interface{}, error) {
+				return api.ListCharacterStoriesWithContext(ctx, characterID, StoryFilter{})
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			t.Run("get", func(t *testing.T) {
+				t.Parallel()
+				var req *http.Request
+				api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(c.json, &req)))
+				v, err := c.get(api, c.id)
+				require.NoError(t, err)
+				require.Equal(t, "/v1/public/"+c.name+"/"+strconv.Itoa(c.id), req.URL.Path)
+				c.checkGet(t, v)
+			})
+			t.Run("get_mismatch_id", func(t *testing.T) {
+				t.Parallel()
+				var req *http.Request
+				api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(c.json, &req)))
+				v, err := c.get(api, 1)
+				require.ErrorIs(t, err, ErrMalformedResponse)
+				require.Nil(t, v)
+			})
+			t.Run("list", func(t *testing.T) {
+				t.Parallel()
+				var req *http.Request
+				api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(c.json, &req)))
+				v, err := c.list(api)
+				require.NoError(t, err)
+				require.Equal(t, "/v1/public/"+c.name, req.URL.Path)
+				for key := range c.query {
+					require.Equal(t, c.query.Get(key), req.URL.Query().Get(key), key)
+				}
+				require.Equal(t, []int{c.id}, resultIDs(t, v))
+			})
+			t.Run("character", func(t *testing.T) {
+				t.Parallel()
+				var req *http.Request
+				api := NewAPI("marvel.test", "", "", WithTransport(newRecordingTransport(c.json, &req)))
+				v, err := c.listByCharacter(api, 1011334)
+				require.NoError(t, err)
+				require.Equal(t, "/v1/public/characters/1011334/"+c.name, req.URL.Path)
+				require.Equal(t, []int{c.id}, resultIDs(t, v))
+			})
+		})
+	}
+}
+
+// resultIDs returns the ids of the results of a list
+func resultIDs(t *testing.T, list interface{}) []int {
+	b, err := json.Marshal(list)
+	require.NoError(t, err)
+	var decoded struct {
+		Total   int `json:"total"`
+		Results []struct {
+			ID int `json:"id"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Equal(t, len(decoded.Results), decoded.Total)
+	ids := make([]int, 0, len(decoded.Results))
+	for _, result := range decoded.Results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
@@ -0,0 +1,141 @@
+package marvel
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy defines how failed requests to marvel api are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, values <= 1 disable retry
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry, it doubles on every next retry
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts, zero means no cap
+	// a Retry-After longer than MaxBackoff stops retrying
+	MaxBackoff time.Duration
+	// Jitter in [0, 1] is the fraction of the backoff randomized to spread retries of concurrent requests
+	Jitter float64
+	// RetryableStatusCodes are the http status codes worth retrying
+	// network errors are always retried
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy is the retry policy used by NewAPI
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Jitter:      0.2,
+	RetryableStatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy sets the retry policy applied to every request
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(api *API) {
+		api.retryPolicy = policy
+	}
+}
+
+// APIStats holds the request counters of an API
+type APIStats struct {
+	// Requests is the number of attempts sent to marvel api, retries included
+	Requests uint64
+	// Retries is the number of attempts which were retries of a failed one
+	Retries uint64
+	// Failures is the number of requests which failed after all attempts
+	Failures uint64
+}
+
+type apiStats struct {
+	requests uint64
+	retries  uint64
+	failures uint64
+}
+
+// nextAttempt tells whether the attempt failed with err should be retried and how long to wait before
+func (p RetryPolicy) nextAttempt(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	wait := p.backoff(attempt)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	switch {
+	case apiErr.StatusCode != 0:
+		if !p.retryable(apiErr.StatusCode) {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+				return 0, false
+			}
+			wait = apiErr.RetryAfter
+		}
+	case errors.Is(apiErr, ErrUpstreamUnavailable):
+		// network error, no response received
+	default:
+		return 0, false
+	}
+	return wait, true
+}
+
+func (p RetryPolicy) retryable(statusCode int) bool {
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff computes the exponential backoff with jitter after the attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		wait += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(wait))
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// parseRetryAfter parses the Retry-After header which is either delay seconds or a http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyTransport fails the first n requests with status then serves the sample json
+func newFlakyTransport(n int32, status int, header http.Header) http.RoundTripper {
+	var calls int32
+	success := test.NewMockTransport(test.NewMockHandler(test.SampleJsonFromMarvel))
+	return test.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&calls, 1) > n {
+			return success.RoundTrip(r)
+		}
+		rec := httptest.NewRecorder()
+		for k, v := range header {
+			rec.Header()[k] = v
+		}
+		rec.WriteHeader(status)
+		resp := rec.Result()
+		resp.Request = r
+		return resp, nil
+	})
+}
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	return policy
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+	t.Run("retry_until_success", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newFlakyTransport(2, http.StatusServiceUnavailable, nil)),
+			WithRetryPolicy(testRetryPolicy()),
+		)
+		info, err := api.GetCharacterInfo(1011334)
+		require.NoError(t, err)
+		require.Equal(t, 1011334, info.ID)
+		require.Equal(t, APIStats{Requests: 3, Retries: 2}, api.Stats())
+	})
+	t.Run("max_attempts_reached", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newFlakyTransport(5, http.StatusBadGateway, nil)),
+			WithRetryPolicy(testRetryPolicy()),
+		)
+		list, total, err := api.DoGetListCharacters(0, API_LIMIT)
+		require.Error(t, err)
+		require.Empty(t, list)
+		require.Zero(t, total)
+		require.Equal(t, APIStats{Requests: 3, Retries: 2, Failures: 1}, api.Stats())
+	})
+	t.Run("not_retryable_status", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newFlakyTransport(1, http.StatusNotFound, nil)),
+			WithRetryPolicy(testRetryPolicy()),
+		)
+		info, err := api.GetCharacterInfo(1011334)
+		require.Error(t, err)
+		require.Nil(t, info)
+		require.Equal(t, APIStats{Requests: 1, Failures: 1}, api.Stats())
+	})
+	t.Run("retry_after_too_long", func(t *testing.T) {
+		t.Parallel()
+		header := http.Header{}
+		header.Set("Retry-After", "60")
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newFlakyTransport(1, http.StatusTooManyRequests, header)),
+			WithRetryPolicy(testRetryPolicy()),
+		)
+		info, err := api.GetCharacterInfo(1011334)
+		require.Error(t, err)
+		require.Nil(t, info)
+		require.Equal(t, uint64(0), api.Stats().Retries)
+	})
+	t.Run("context_canceled_while_waiting", func(t *testing.T) {
+		t.Parallel()
+		policy := testRetryPolicy()
+		policy.BaseBackoff = time.Minute
+		policy.MaxBackoff = time.Minute
+		api := NewAPI("marvel.test", "", "",
+			WithTransport(newFlakyTransport(1, http.StatusServiceUnavailable, nil)),
+			WithRetryPolicy(policy),
+		)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		info, err := api.GetCharacterInfoWithContext(ctx, 1011334)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Nil(t, info)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+	require.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	require.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	require.Equal(t, 400*time.Millisecond, policy.backoff(3))
+	require.Equal(t, time.Second, policy.backoff(10))
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := policy.backoff(1)
+		require.GreaterOrEqual(t, int64(wait), int64(50*time.Millisecond))
+		require.LessOrEqual(t, int64(wait), int64(150*time.Millisecond))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	require.Zero(t, parseRetryAfter("", now))
+	require.Zero(t, parseRetryAfter("invalid", now))
+	require.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	require.Equal(t, 5*time.Second, parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now))
+	require.Zero(t, parseRetryAfter(now.Add(-time.Second).Format(http.TimeFormat), now))
+}
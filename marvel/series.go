@@ -0,0 +1,124 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Series is the full series record
+type Series struct {
+	ID          int              `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	ResourceURI string           `json:"resourceURI"`
+	URLs        []URL            `json:"urls"`
+	StartYear   int              `json:"startYear"`
+	EndYear     int              `json:"endYear"`
+	Rating      string           `json:"rating"`
+	Type        string           `json:"type"`
+	Modified    Time             `json:"modified"`
+	Thumbnail   Image            `json:"thumbnail"`
+	Creators    ResourceList     `json:"creators"`
+	Characters  ResourceList     `json:"characters"`
+	Stories     ResourceList     `json:"stories"`
+	Comics      ResourceList     `json:"comics"`
+	Events      ResourceList     `json:"events"`
+	Next        *ResourceSummary `json:"next"`
+	Previous    *ResourceSummary `json:"previous"`
+}
+
+// SeriesList is a page of series
+type SeriesList struct {
+	Page
+	Results []*Series `json:"results"`
+}
+
+// SeriesFilter are the marvel filters of a series list, zero values are ignored
+type SeriesFilter struct {
+	ListOptions
+	Title           string
+	TitleStartsWith string
+	StartYear       int
+	// SeriesType is one of collection, one shot, limited, ongoing
+	SeriesType string
+	// Contains is a comic format the series must contain (comic, magazine, trade paperback...)
+	Contains   string
+	Comics     []int
+	Stories    []int
+	Events     []int
+	Creators   []int
+	Characters []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f SeriesFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setString(query, "title", f.Title)
+	setString(query, "titleStartsWith", f.TitleStartsWith)
+	setInt(query, "startYear", f.StartYear)
+	setString(query, "seriesType", f.SeriesType)
+	setString(query, "contains", f.Contains)
+	setInts(query, "comics", f.Comics)
+	setInts(query, "stories", f.Stories)
+	setInts(query, "events", f.Events)
+	setInts(query, "creators", f.Creators)
+	setInts(query, "characters", f.Characters)
+	return query
+}
+
+// GetSeries get the series by id
+func (api *API) GetSeries(id int) (*Series, error) {
+	return api.GetSeriesWithContext(context.Background(), id)
+}
+
+// GetSeriesWithContext get the series by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetSeriesWithContext(ctx context.Context, id int) (*Series, error) {
+	var results []*Series
+	if _, err := api.getResults(ctx, "v1/public/series/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no series found for id %d: %w", id, ErrNotFound)
+	}
+	series := results[0]
+	if series.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, series.ID)
+	}
+	return series, nil
+}
+
+// ListSeries get a page of series matching filter
+func (api *API) ListSeries(filter SeriesFilter) (*SeriesList, error) {
+	return api.ListSeriesWithContext(context.Background(), filter)
+}
+
+// ListSeriesWithContext get a page of series matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListSeriesWithContext(ctx context.Context, filter SeriesFilter) (*SeriesList, error) {
+	return api.listSeries(ctx, "v1/public/series", filter)
+}
+
+// ListCharacterSeries get a page of series featuring the character matching filter
+func (api *API) ListCharacterSeries(characterID int, filter SeriesFilter) (*SeriesList, error) {
+	return api.ListCharacterSeriesWithContext(context.Background(), characterID, filter)
+}
+
+// ListCharacterSeriesWithContext get a page of series featuring the character matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCharacterSeriesWithContext(ctx context.Context, characterID int, filter SeriesFilter) (*SeriesList, error) {
+	return api.listSeries(ctx, "v1/public/characters/"+strconv.Itoa(characterID)+"/series", filter)
+}
+
+func (api *API) listSeries(ctx context.Context, path string, filter SeriesFilter) (*SeriesList, error) {
+	list := new(SeriesList)
+	data, err := api.getResults(ctx, path, filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
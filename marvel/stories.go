@@ -0,0 +1,107 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Story is the full story record, stories are the indivisible pieces of comics
+type Story struct {
+	ID            int              `json:"id"`
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	ResourceURI   string           `json:"resourceURI"`
+	Type          string           `json:"type"`
+	Modified      Time             `json:"modified"`
+	Thumbnail     *Image           `json:"thumbnail"`
+	Creators      ResourceList     `json:"creators"`
+	Characters    ResourceList     `json:"characters"`
+	Series        ResourceList     `json:"series"`
+	Comics        ResourceList     `json:"comics"`
+	Events        ResourceList     `json:"events"`
+	OriginalIssue *ResourceSummary `json:"originalIssue"`
+}
+
+// StoryList is a page of stories
+type StoryList struct {
+	Page
+	Results []*Story `json:"results"`
+}
+
+// StoryFilter are the marvel filters of a story list, zero values are ignored
+type StoryFilter struct {
+	ListOptions
+	Comics     []int
+	Series     []int
+	Events     []int
+	Creators   []int
+	Characters []int
+}
+
+// Values encodes the filter as marvel query parameters
+func (f StoryFilter) Values() url.Values {
+	query := url.Values{}
+	f.ListOptions.setValues(query)
+	setInts(query, "comics", f.Comics)
+	setInts(query, "series", f.Series)
+	setInts(query, "events", f.Events)
+	setInts(query, "creators", f.Creators)
+	setInts(query, "characters", f.Characters)
+	return query
+}
+
+// GetStory get the story by id
+func (api *API) GetStory(id int) (*Story, error) {
+	return api.GetStoryWithContext(context.Background(), id)
+}
+
+// GetStoryWithContext get the story by id
+// the request is aborted as soon as ctx is done
+func (api *API) GetStoryWithContext(ctx context.Context, id int) (*Story, error) {
+	var results []*Story
+	if _, err := api.getResults(ctx, "v1/public/stories/"+strconv.Itoa(id), nil, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no story found for id %d: %w", id, ErrNotFound)
+	}
+	story := results[0]
+	if story.ID != id {
+		return nil, malformedError("invalid id repsponded: want %d got %d", id, story.ID)
+	}
+	return story, nil
+}
+
+// ListStories get a page of stories matching filter
+func (api *API) ListStories(filter StoryFilter) (*StoryList, error) {
+	return api.ListStoriesWithContext(context.Background(), filter)
+}
+
+// ListStoriesWithContext get a page of stories matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListStoriesWithContext(ctx context.Context, filter StoryFilter) (*StoryList, error) {
+	return api.listStories(ctx, "v1/public/stories", filter)
+}
+
+// ListCharacterStories get a page of stories featuring the character matching filter
+func (api *API) ListCharacterStories(characterID int, filter StoryFilter) (*StoryList, error) {
+	return api.ListCharacterStoriesWithContext(context.Background(), characterID, filter)
+}
+
+// ListCharacterStoriesWithContext get a page of stories featuring the character matching filter
+// the request is aborted as soon as ctx is done
+func (api *API) ListCharacterStoriesWithContext(ctx context.Context, characterID int, filter StoryFilter) (*StoryList, error) {
+	return api.listStories(ctx, "v1/public/characters/"+strconv.Itoa(characterID)+"/stories", filter)
+}
+
+func (api *API) listStories(ctx context.Context, path string, filter StoryFilter) (*StoryList, error) {
+	list := new(StoryList)
+	data, err := api.getResults(ctx, path, filter.Values(), &list.Results)
+	if err != nil {
+		return nil, err
+	}
+	list.Page = newPage(data)
+	return list, nil
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+)
+
+const (
+	// the number of keys listed by GetCacheStats by default and at most
+	Admin_Keys_Limit     = 100
+	Admin_Keys_Max_Limit = 1000
+)
+
+// CacheDeleteResult is the response of the admin cache deletions
+type CacheDeleteResult struct {
+	// Deleted is the number of deleted keys, -1 when the whole cache is cleared
+	Deleted int `json:"deleted"`
+}
+
+// CacheStatsResult is the response of the admin cache stats
+type CacheStatsResult struct {
+	Stats cacher.Stats `json:"stats"`
+	// Tiers is set for a tiered cache
+	Tiers *cacher.TierStats `json:"tiers,omitempty"`
+	// Keys are the first keys starting with the prefix query parameter, TotalKeys is the number of such keys
+	// they are not set when the cache keys can't be listed
+	Keys      []string `json:"keys,omitempty"`
+	TotalKeys int      `json:"totalKeys"`
+}
+
+// CacheKeyResult is the response of the admin cache key inspection
+// the value of a key cached with its time is the encoded value, it is empty if the codec isn't json
+type CacheKeyResult struct {
+	cacher.KeyInfo
+	// Codec is the codec of a value cached with its time
+	Codec string `json:"codec,omitempty"`
+	// Missing is set for a resource cached as not found
+	Missing bool `json:"missing,omitempty"`
+	// AgeSeconds is how long ago the value was cached, it isn't set for a value cached without its time
+	AgeSeconds *int64 `json:"ageSeconds,omitempty"`
+	// TTLSeconds is the remaining time to live, it isn't set when the key never expires
+	TTLSeconds *int64 `json:"ttlSeconds,omitempty"`
+}
+
+// tierStatsReporter is a tiered cache
+type tierStatsReporter interface {
+	TierStats() cacher.TierStats
+}
+
+// adminOnly protects the admin handlers with the bearer token set in ADMIN_TOKEN
+// the admin routes are not found when no token is configured
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			writeProblem(w, http.StatusNotFound, "not found")
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeProblem(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// DeleteCache clears the cache, or only the keys starting with the prefix query parameter
+// the decoded values, the encoded responses and the name index kept in process are dropped with the keys
+func (s *Server) DeleteCache(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		s.cacher.Clear()
+		s.values.Clear()
+		s.responses.Clear()
+		s.index.Reset()
+		writeJSON(w, &CacheDeleteResult{Deleted: -1})
+		return
+	}
+	deleted := s.cacher.DeletePrefix(prefix)
+	s.invalidateResponses(s.responses.Keys(prefix)...)
+	if strings.HasPrefix(Characters_Summary_Cache_Key, prefix) {
+		// the name index is built from the character records, it goes with them
+		s.index.Reset()
+	}
+	writeJSON(w, &CacheDeleteResult{Deleted: deleted})
+}
+
+// DeleteCacheKey deletes a single cache key
+func (s *Server) DeleteCacheKey(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	if !s.cacher.Delete(key) {
+		writeProblem(w, http.StatusNotFound, "cache key not found")
+		return
+	}
+	s.invalidateResponses(key)
+	if key == Characters_Summary_Cache_Key {
+		s.index.Reset()
+	}
+	writeJSON(w, &CacheDeleteResult{Deleted: 1})
+}
+
+// GetCacheStats responds the stats of the cache and its keys starting with the prefix query parameter
+// the limit query parameter is the number of listed keys
+func (s *Server) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := s.cacher.(cacher.StatsReporter)
+	if !ok {
+		writeProblem(w, http.StatusNotImplemented, "cache stats unsupported by the cache backend")
+		return
+	}
+	limit, err := queryInt(r.URL.Query(), "limit")
+	if err != nil || limit < 0 || limit > Admin_Keys_Max_Limit {
+		writeProblem(w, http.StatusBadRequest, "limit must be an integer between 0 and 1000")
+		return
+	}
+	if limit == 0 {
+		limit = Admin_Keys_Limit
+	}
+	result := &CacheStatsResult{Stats: reporter.Stats()}
+	if tiered, ok := s.cacher.(tierStatsReporter); ok {
+		tiers := tiered.TierStats()
+		result.Tiers = &tiers
+	}
+	if inspector, ok := s.cacher.(cacher.Inspector); ok {
+		result.Keys, result.TotalKeys = inspector.Keys(r.URL.Query().Get("prefix"), limit)
+	}
+	writeJSON(w, result)
+}
+
+// GetCacheKey responds the value, the age and the time to live of a cache key
+func (s *Server) GetCacheKey(w http.ResponseWriter, r *http.Request) {
+	inspector, ok := s.cacher.(cacher.Inspector)
+	if !ok {
+		writeProblem(w, http.StatusNotImplemented, "cache inspection unsupported by the cache backend")
+		return
+	}
+	info, ok := inspector.Inspect(mux.Vars(r)["key"])
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "cache key not found")
+		return
+	}
+	result := &CacheKeyResult{KeyInfo: info}
+	now := s.clock()
+	if entry, ok := cacher.DecodeEntry(info.Value); ok {
+		age := int64(now.Sub(entry.StoredAt) / time.Second)
+		result.AgeSeconds = &age
+		result.Codec, result.Missing = entry.Codec, entry.Missing
+		result.Value = ""
+		if entry.Codec == cacher.Codec_JSON {
+			result.Value = string(entry.Value)
+		}
+	}
+	if !info.ExpiresAt.IsZero() {
+		ttl := int64(info.ExpiresAt.Sub(now) / time.Second)
+		result.TTLSeconds = &ttl
+	}
+	writeJSON(w, result)
+}
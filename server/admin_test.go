@@ -0,0 +1,276 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+// plainCacher is a cache without the optional capabilities
+type plainCacher struct {
+	cacher.Cacher
+}
+
+func TestAdminOnly(t *testing.T) {
+	t.Parallel()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	tests := []struct {
+		name   string
+		token  string
+		auth   string
+		status int
+	}{
+		{name: "disabled", token: "", auth: "Bearer ", status: http.StatusNotFound},
+		{name: "missing", token: "secret", auth: "", status: http.StatusUnauthorized},
+		{name: "not_bearer", token: "secret", auth: "secret", status: http.StatusUnauthorized},
+		{name: "invalid", token: "secret", auth: "Bearer wrong", status: http.StatusUnauthorized},
+		{name: "valid", token: "secret", auth: "Bearer secret", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := &Server{adminToken: tt.token}
+			req := httptest.NewRequest(http.MethodDelete, "/admin/cache", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			s.adminOnly(handler)(rec, req)
+			require.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestDeleteCache(t *testing.T) {
+	t.Parallel()
+	newServer := func() *Server {
+		s := &Server{cacher: cacher.NewCacher()}
+		s.cacher.Set(buildCharacterInfoCacheKey(1), "{}")
+		s.cacher.Set(buildCharacterInfoCacheKey(2), "{}")
+		s.cacher.Set(Characters_Cache_Key, "[1,2]")
+		return s
+	}
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) CacheDeleteResult {
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result CacheDeleteResult
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		return result
+	}
+	t.Run("prefix", func(t *testing.T) {
+		t.Parallel()
+		s := newServer()
+		rec := httptest.NewRecorder()
+		s.DeleteCache(rec, httptest.NewRequest(http.MethodDelete, "/admin/cache?prefix="+Character_Info_Cache_Key+"_", nil))
+		require.Equal(t, 2, decode(t, rec).Deleted)
+		_, ok := s.cacher.Get(Characters_Cache_Key)
+		require.True(t, ok)
+	})
+	t.Run("clear", func(t *testing.T) {
+		t.Parallel()
+		s := newServer()
+		rec := httptest.NewRecorder()
+		s.DeleteCache(rec, httptest.NewRequest(http.MethodDelete, "/admin/cache", nil))
+		require.Equal(t, -1, decode(t, rec).Deleted)
+		_, ok := s.cacher.Get(Characters_Cache_Key)
+		require.False(t, ok)
+	})
+	t.Run("clear_in_process", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
+		require.NoError(t, err)
+		s := &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+			cacher:    cacher.NewCacher(),
+			values:    cacher.NewValueCache(10),
+			responses: cacher.NewValueCache(10),
+		}
+		s.storeCharacterRecords([]*marvel.MarvelCharacter{{ID: 1, Name: "Stale Man"}})
+		s.setCached(Characters_Cache_Key, &[]int{1}, Character_List_Cache_TTL)
+		rec := getCharacterList(s, "/characters", http.Header{})
+		require.Equal(t, http.StatusOK, rec.Code)
+		search := func() (*httptest.ResponseRecorder, CharacterSearchResult) {
+			rec := httptest.NewRecorder()
+			s.SearchCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters/search?nameStartsWith=stale", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			var result CharacterSearchResult
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+			return rec, result
+		}
+		rec, result := search()
+		require.Equal(t, "index", rec.Header().Get("X-Search-Source"))
+		require.Equal(t, "Stale Man", result.Results[0].Name)
+		require.NotEmpty(t, s.responses.Keys(""))
+
+		rec = httptest.NewRecorder()
+		s.DeleteCache(rec, httptest.NewRequest(http.MethodDelete, "/admin/cache", nil))
+		require.Equal(t, -1, decode(t, rec).Deleted)
+		require.Empty(t, s.values.Keys(""))
+		require.Empty(t, s.responses.Keys(""))
+		// the names of the flushed records aren't searched anymore
+		rec, result = search()
+		require.Equal(t, "marvel", rec.Header().Get("X-Search-Source"))
+		for _, character := range result.Results {
+			require.NotEqual(t, "Stale Man", character.Name)
+		}
+	})
+	t.Run("prefix_in_process", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{
+			marvelAPI: marvel.NewAPI("", "", ""),
+			cacher:    cacher.NewCacher(),
+			responses: cacher.NewValueCache(10),
+		}
+		s.storeCharacterRecords([]*marvel.MarvelCharacter{{ID: 1, Name: "Stale Man"}})
+		s.setCached(Characters_Cache_Key, &[]int{1}, Character_List_Cache_TTL)
+		s.setCached(buildCharacterInfoCacheKey(2), &marvel.MarvelCharacter{ID: 2, Name: "two"}, Detail_Cache_TTL)
+		rec := getCharacterList(s, "/characters", http.Header{})
+		require.Equal(t, http.StatusOK, rec.Code)
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/characters/2", nil)
+		s.GetCharacterInfo(rec, mux.SetURLVars(req, map[string]string{"id": "2"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, []string{buildCharacterInfoCacheKey(2), Characters_Cache_Key}, s.responses.Keys(""))
+
+		rec = httptest.NewRecorder()
+		s.DeleteCache(rec, httptest.NewRequest(http.MethodDelete, "/admin/cache?prefix="+Characters_Cache_Key, nil))
+		decode(t, rec)
+		require.Equal(t, []string{buildCharacterInfoCacheKey(2)}, s.responses.Keys(""))
+		require.False(t, s.index.Ready())
+	})
+	t.Run("key", func(t *testing.T) {
+		t.Parallel()
+		s := newServer()
+		req := httptest.NewRequest(http.MethodDelete, "/admin/cache/"+Characters_Cache_Key, nil)
+		req = mux.SetURLVars(req, map[string]string{"key": Characters_Cache_Key})
+		rec := httptest.NewRecorder()
+		s.DeleteCacheKey(rec, req)
+		require.Equal(t, 1, decode(t, rec).Deleted)
+
+		rec = httptest.NewRecorder()
+		s.DeleteCacheKey(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestGetCacheStats(t *testing.T) {
+	t.Parallel()
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) CacheStatsResult {
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result CacheStatsResult
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		return result
+	}
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: cacher.NewCacher()}
+		s.cacher.Set(buildCharacterInfoCacheKey(1), "{}")
+		s.cacher.Set(buildCharacterInfoCacheKey(2), "{}")
+		s.cacher.Set(Characters_Cache_Key, "[1,2]")
+		s.cacher.Get(Characters_Cache_Key)
+		s.cacher.Get(Characters_Summary_Cache_Key)
+
+		rec := httptest.NewRecorder()
+		s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache", nil))
+		result := decode(t, rec)
+		require.Equal(t, cacher.Stats{Hits: 1, Misses: 1, Sets: 3, Entries: 3, Bytes: 51}, result.Stats)
+		require.Nil(t, result.Tiers)
+		require.Equal(t, 3, result.TotalKeys)
+
+		rec = httptest.NewRecorder()
+		s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache?prefix="+Character_Info_Cache_Key+"_&limit=1", nil))
+		result = decode(t, rec)
+		require.Equal(t, []string{buildCharacterInfoCacheKey(1)}, result.Keys)
+		require.Equal(t, 2, result.TotalKeys)
+	})
+	t.Run("tiered", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: cacher.NewTieredCacher(cacher.NewCacher(), cacher.NewCacher())}
+		s.cacher.Set(Characters_Cache_Key, "[1,2]")
+		rec := httptest.NewRecorder()
+		s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache", nil))
+		result := decode(t, rec)
+		require.NotNil(t, result.Tiers)
+		require.Equal(t, uint64(1), result.Tiers.Writes)
+		require.Equal(t, []string{Characters_Cache_Key}, result.Keys)
+	})
+	t.Run("redis", func(t *testing.T) {
+		t.Parallel()
+		redis, err := test.NewRedisServer("")
+		require.NoError(t, err)
+		defer redis.Close()
+		s := &Server{cacher: cacher.NewRedisCacher(redis.Addr(), cacher.WithRedisKeyPrefix(Redis_Default_Prefix))}
+		s.cacher.Set(Characters_Cache_Key, "[1,2]")
+		s.cacher.Get(Characters_Cache_Key)
+		rec := httptest.NewRecorder()
+		s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache", nil))
+		result := decode(t, rec)
+		require.Equal(t, cacher.Stats{Hits: 1, Sets: 1}, result.Stats)
+		require.Equal(t, []string{Characters_Cache_Key}, result.Keys)
+	})
+	t.Run("invalid_limit", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: cacher.NewCacher()}
+		for _, limit := range []string{"abc", "-1", "1001"} {
+			rec := httptest.NewRecorder()
+			s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache?limit="+limit, nil))
+			require.Equal(t, http.StatusBadRequest, rec.Code, limit)
+		}
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: plainCacher{cacher.NewCacher()}}
+		rec := httptest.NewRecorder()
+		s.GetCacheStats(rec, httptest.NewRequest(http.MethodGet, "/admin/cache", nil))
+		require.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+}
+
+func TestGetCacheKey(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{
+		cacher: cacher.NewCacher(),
+		now:    func() time.Time { return now },
+	}
+	s.setCached(Characters_Cache_Key, []int{1, 2}, time.Hour)
+	s.cacher.Set("legacy", "[1,2]")
+	get := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/cache/"+key, nil)
+		rec := httptest.NewRecorder()
+		s.GetCacheKey(rec, mux.SetURLVars(req, map[string]string{"key": key}))
+		return rec
+	}
+	now = now.Add(time.Minute)
+
+	rec := get(Characters_Cache_Key)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var result CacheKeyResult
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+	require.Equal(t, Characters_Cache_Key, result.Key)
+	require.Equal(t, cacher.Codec_JSON, result.Codec)
+	require.JSONEq(t, "[1,2]", result.Value)
+	require.Equal(t, int64(60), *result.AgeSeconds)
+	// the memory cache expiry follows the real clock
+	require.NotNil(t, result.TTLSeconds)
+
+	rec = get("legacy")
+	require.Equal(t, http.StatusOK, rec.Code)
+	result = CacheKeyResult{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+	require.Equal(t, "[1,2]", result.Value)
+	require.Nil(t, result.AgeSeconds)
+	require.Nil(t, result.TTLSeconds)
+
+	require.Equal(t, http.StatusNotFound, get("missing").Code)
+}
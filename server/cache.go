@@ -0,0 +1,397 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	// CACHE_WRITE_MODE is how the second cache tier is written: through (default) or behind
+	CACHE_WRITE_MODE = "CACHE_WRITE_MODE"
+
+	// CACHE_SHARDS is the number of shards of the memory cache, 1 doesn't shard it
+	CACHE_SHARDS = "CACHE_SHARDS"
+
+	Cache_Write_Through = "through"
+	Cache_Write_Behind  = "behind"
+
+	// the first tier is a small cache in front of the shared one
+	Cache_L1_Max_Entries = 5000
+	// Cache_L1_TTL bounds how long a replica serves a key changed in the shared tier
+	Cache_L1_TTL             = time.Minute
+	Cache_Write_Behind_Queue = 1000
+	// the memory cache is sharded so that concurrent requests don't contend on one lock
+	Cache_Default_Shards = 16
+
+	// SERVE_STALE_ON_ERROR serves the expired values while marvel is unavailable when set to true
+	SERVE_STALE_ON_ERROR = "SERVE_STALE_ON_ERROR"
+
+	// Stale_While_Revalidate is how long a value is still served once its ttl is over, while it is refreshed in background
+	Stale_While_Revalidate = time.Hour
+	// Stale_If_Error is how long a value is kept for SERVE_STALE_ON_ERROR once its ttl is over
+	Stale_If_Error = 24 * time.Hour
+	// the background refresh isn't bound to a request, it has its own timeout
+	Revalidate_Timeout = 30 * time.Second
+	// Fetch_Timeout bounds a fetch shared by concurrent requests, it isn't bound to any of them
+	Fetch_Timeout = time.Minute
+
+	// NOT_FOUND_CACHE_TTL is how long an unknown character id is cached as not found e.g. 30s, 0 disables it
+	NOT_FOUND_CACHE_TTL = "NOT_FOUND_CACHE_TTL"
+	// a character may be created anytime, the not found ids are cached shortly
+	Not_Found_Cache_TTL = time.Minute
+
+	// CACHE_CODEC is how the cached values are encoded: json (default), gob or binary
+	// the binary codec encodes the id lists compactly, the other values are encoded in json
+	CACHE_CODEC = "CACHE_CODEC"
+	// Cache_Decoded_Max_Entries is the number of decoded values kept so that a hit doesn't decode them again
+	Cache_Decoded_Max_Entries = 1000
+)
+
+// errNotFoundCached is returned for a resource cached as not found
+var errNotFoundCached = fmt.Errorf("cached as not found: %w", marvel.ErrNotFound)
+
+// newCacher creates the cache configured by CACHE_BACKEND
+// with two backends, the first one is layered over the second one
+func newCacher() (cacher.Cacher, error) {
+	backends := strings.Split(os.Getenv(CACHE_BACKEND), ",")
+	if len(backends) == 1 {
+		return newCacheBackend(strings.TrimSpace(backends[0]), false)
+	}
+	if len(backends) != 2 {
+		return nil, fmt.Errorf("at most 2 cache backends are supported, got %d", len(backends))
+	}
+	var opts []cacher.TieredOption
+	switch mode := os.Getenv(CACHE_WRITE_MODE); mode {
+	case "", Cache_Write_Through:
+	case Cache_Write_Behind:
+		opts = append(opts, cacher.WithWriteBehind(Cache_Write_Behind_Queue))
+	default:
+		return nil, fmt.Errorf("unknown cache write mode %s", mode)
+	}
+	l1, err := newCacheBackend(strings.TrimSpace(backends[0]), true)
+	if err != nil {
+		return nil, err
+	}
+	l2, err := newCacheBackend(strings.TrimSpace(backends[1]), false)
+	if err != nil {
+		closeCacher(l1)
+		return nil, err
+	}
+	return cacher.NewTieredCacher(l1, l2, append(opts, cacher.WithL1TTL(Cache_L1_TTL))...), nil
+}
+
+// newCodec returns the codec configured by CACHE_CODEC
+func newCodec() (cacher.Codec, error) {
+	name := os.Getenv(CACHE_CODEC)
+	if name == "" {
+		return cacher.JSONCodec, nil
+	}
+	codec, ok := cacher.CodecByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown cache codec %s", name)
+	}
+	return codec, nil
+}
+
+// newCacheBackend creates the cache of a backend, a first tier memory cache is smaller
+// the redis cache falls back to a memory cache while redis is unreachable
+func newCacheBackend(backend string, l1 bool) (cacher.Cacher, error) {
+	maxEntries := Cache_Max_Entries
+	if l1 {
+		maxEntries = Cache_L1_Max_Entries
+	}
+	shards := Cache_Default_Shards
+	if v, found := os.LookupEnv(CACHE_SHARDS); found {
+		var err error
+		shards, err = strconv.Atoi(v)
+		if err != nil || shards < 1 {
+			return nil, fmt.Errorf("invalid value %s for environment variable %s", v, CACHE_SHARDS)
+		}
+	}
+	switch backend {
+	case "", Cache_Backend_Memory:
+		return newMemoryCacher(maxEntries, shards)
+	case Cache_Backend_Redis:
+		fallback, err := newMemoryCacher(maxEntries, shards)
+		if err != nil {
+			return nil, err
+		}
+		addr := os.Getenv(REDIS_ADDR)
+		if addr == "" {
+			addr = Redis_Default_Addr
+		}
+		prefix, found := os.LookupEnv(REDIS_KEY_PREFIX)
+		if !found {
+			prefix = Redis_Default_Prefix
+		}
+		return cacher.NewRedisCacher(addr,
+			cacher.WithRedisDefaultTTL(Detail_Cache_TTL),
+			cacher.WithRedisPassword(os.Getenv(REDIS_PASSWORD)),
+			cacher.WithRedisKeyPrefix(prefix),
+			cacher.WithRedisFallback(fallback),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %s", backend)
+	}
+}
+
+func newMemoryCacher(maxEntries, shards int) (cacher.Cacher, error) {
+	opts := []cacher.Option{
+		cacher.WithDefaultTTL(Detail_Cache_TTL),
+		cacher.WithCleanupInterval(Cache_Cleanup_Interval),
+		cacher.WithMaxEntries(maxEntries),
+		cacher.WithMaxBytes(Cache_Max_Bytes),
+	}
+	if shards > 1 {
+		return cacher.NewShardedCacher(shards, opts...)
+	}
+	return cacher.NewCacher(opts...), nil
+}
+
+func closeCacher(c cacher.Cacher) {
+	if closer, ok := c.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// getCached gets the value of cacheKey from cache, on cache miss it fetches and caches the value
+// concurrent calls for the same key are merged into one, the fetch runs under its own Fetch_Timeout with the values of ctx
+// a call returns as soon as ctx is done, the fetch carries on for the other calls and the cache
+// newValue returns the pointer the cached json is decoded into, fetch must return the same type
+// a value derived from another cached value is returned by fetch as a cachedValue, it keeps the time of its source
+// the value is fresh for ttl, then it is served stale for Stale_While_Revalidate while it is refreshed in background
+// older values are fetched again, they are only served when the fetch fails and stale on error is enabled
+func (s *Server) getCached(ctx context.Context,
+	cacheKey string,
+	ttl time.Duration,
+	newValue func() interface{},
+	fetch func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	cached, err := s.getCachedValue(ctx, cacheKey, ttl, 0, newValue, fetch)
+	return cached.value, err
+}
+
+// getCachedValue is getCached returning the value with the time it was cached
+// a resource which doesn't exist is cached for notFoundTTL, the not found resources aren't cached when notFoundTTL is 0
+func (s *Server) getCachedValue(ctx context.Context,
+	cacheKey string,
+	ttl time.Duration,
+	notFoundTTL time.Duration,
+	newValue func() interface{},
+	fetch func(ctx context.Context) (interface{}, error),
+) (cachedValue, error) {
+	result := s.requestGroup.DoChan(cacheKey, func() (interface{}, error) {
+		// the fetch is shared, the caller which started it may leave before it is done
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, Fetch_Timeout)
+		defer cancel()
+		// get from cache first
+		cached, found := s.lookupCached(cacheKey, newValue)
+		if found && cached.missing {
+			return nil, errNotFoundCached
+		}
+		if found && cached.age < ttl {
+			return cached, nil
+		}
+		if found && cached.age < ttl+Stale_While_Revalidate {
+			s.revalidate(ctx, cacheKey, ttl, notFoundTTL, fetch)
+			return cached, nil
+		}
+		v, err := fetch(ctx)
+		if err != nil {
+			if found && s.serveStaleOnError && isTransientError(err) {
+				// log error
+				log.Println("serve stale on error", cacheKey, err)
+				return cached, nil
+			}
+			s.setFetchError(cacheKey, notFoundTTL, err)
+			return nil, err
+		}
+		return s.setFetched(cacheKey, v, ttl), nil
+	})
+	select {
+	case <-ctx.Done():
+		return cachedValue{}, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return cachedValue{}, res.Err
+		}
+		return res.Val.(cachedValue), nil
+	}
+}
+
+// cachedValue is a value decoded from cache
+type cachedValue struct {
+	value interface{}
+	// storedAt is zero for a value cached without its time
+	storedAt time.Time
+	// age is how long ago the value was cached
+	age time.Duration
+	// missing is set when the resource was cached as not found
+	missing bool
+}
+
+// lookupCached decodes the cached value of cacheKey
+// a value cached without its time is considered fresh
+// the decoded value is kept, it is returned as is as long as the cached value doesn't change
+func (s *Server) lookupCached(cacheKey string, newValue func() interface{}) (cachedValue, bool) {
+	value, ok := s.cacher.Get(cacheKey)
+	if !ok {
+		return cachedValue{}, false
+	}
+	if v, ok := s.values.Get(cacheKey, value); ok {
+		cached := v.(cachedValue)
+		if !cached.storedAt.IsZero() {
+			cached.age = s.clock().Sub(cached.storedAt)
+		}
+		return cached, true
+	}
+	entry, ok := cacher.DecodeEntry(value)
+	if !ok {
+		// cached as raw json
+		entry = cacher.Entry{Codec: cacher.Codec_JSON, Value: []byte(value)}
+	}
+	cached := cachedValue{storedAt: entry.StoredAt, missing: entry.Missing}
+	if !cached.storedAt.IsZero() {
+		cached.age = s.clock().Sub(cached.storedAt)
+	}
+	if !cached.missing {
+		codec, ok := cacher.CodecByName(entry.Codec)
+		if !ok {
+			// log error
+			log.Println("decode error", cacheKey, "unknown codec", entry.Codec)
+			return cachedValue{}, false
+		}
+		cached.value = newValue()
+		if err := codec.Unmarshal(entry.Value, cached.value); err != nil {
+			// log error
+			log.Println("decode error", err)
+			return cachedValue{}, false
+		}
+	}
+	s.values.Set(cacheKey, value, cached)
+	return cached, true
+}
+
+// setFetched caches a fetched value, a value derived from another cached value keeps the time of its source
+// so that a derived value isn't fresher than its source
+func (s *Server) setFetched(cacheKey string, v interface{}, ttl time.Duration) cachedValue {
+	if derived, ok := v.(cachedValue); ok {
+		if derived.storedAt.IsZero() {
+			// the source was cached without its time
+			return s.setCached(cacheKey, derived.value, ttl)
+		}
+		return s.setCachedAt(cacheKey, derived.value, ttl, derived.storedAt)
+	}
+	return s.setCached(cacheKey, v, ttl)
+}
+
+// setCached caches v encoded by the codec of the server with the time it is cached
+// the key outlives ttl so that the value can still be served stale
+// it returns v with the time it is cached, the time is zero if v couldn't be encoded
+func (s *Server) setCached(cacheKey string, v interface{}, ttl time.Duration) cachedValue {
+	return s.setCachedAt(cacheKey, v, ttl, s.clock())
+}
+
+// setCachedAt is setCached for a value which is as old as storedAt
+func (s *Server) setCachedAt(cacheKey string, v interface{}, ttl time.Duration, storedAt time.Time) cachedValue {
+	codec := s.codec
+	if codec == nil {
+		codec = cacher.JSONCodec
+	}
+	b, err := codec.Marshal(v)
+	if errors.Is(err, cacher.ErrCodecUnsupported) {
+		// e.g. a struct with the binary codec
+		codec = cacher.JSONCodec
+		b, err = codec.Marshal(v)
+	}
+	if err != nil {
+		// log error
+		log.Println("encode error", err)
+		return cachedValue{value: v}
+	}
+	value := cacher.Entry{StoredAt: storedAt, Codec: codec.Name(), Value: b}.Encode()
+	staleTTL := Stale_While_Revalidate
+	if s.serveStaleOnError {
+		staleTTL = Stale_If_Error
+	}
+	s.cacher.SetWithTTL(cacheKey, value, ttl+staleTTL)
+	cached := cachedValue{value: v, storedAt: storedAt}
+	// the next hit doesn't decode what was just encoded
+	s.values.Set(cacheKey, value, cached)
+	return cached
+}
+
+// setFetchError caches that the resource of cacheKey doesn't exist when the fetch failed with not found
+// the other errors may not happen on the next fetch, they are never cached
+func (s *Server) setFetchError(cacheKey string, notFoundTTL time.Duration, err error) {
+	if notFoundTTL <= 0 || !errors.Is(err, marvel.ErrNotFound) {
+		return
+	}
+	value, err := cacher.EncodeMissingEntry(s.clock())
+	if err != nil {
+		// log error
+		log.Println("encode error", err)
+		return
+	}
+	// the resource may be created anytime, it isn't served stale
+	s.cacher.SetWithTTL(cacheKey, value, notFoundTTL)
+}
+
+// revalidate refreshes the value of cacheKey in background, concurrent refreshes of a key are merged into one
+// the refresh keeps the values of ctx but not its deadline
+func (s *Server) revalidate(ctx context.Context,
+	cacheKey string,
+	ttl time.Duration,
+	notFoundTTL time.Duration,
+	fetch func(ctx context.Context) (interface{}, error),
+) {
+	s.requestGroup.DoChan("revalidate_"+cacheKey, func() (interface{}, error) {
+		// the refresh outlives the request which served the stale value
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, Revalidate_Timeout)
+		defer cancel()
+		v, err := fetch(ctx)
+		if err != nil {
+			// log error
+			log.Println("revalidate error", cacheKey, err)
+			s.setFetchError(cacheKey, notFoundTTL, err)
+			return nil, err
+		}
+		s.setFetched(cacheKey, v, ttl)
+		return v, nil
+	})
+}
+
+// detachedContext keeps the values of its parent, e.g. the priority of the marvel calls, but it is never done
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// isTransientError reports whether err may not happen on retry, a missing or invalid resource is not
+func isTransientError(err error) bool {
+	return !errors.Is(err, marvel.ErrNotFound) && !errors.Is(err, marvel.ErrInvalidParameter)
+}
+
+func (s *Server) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
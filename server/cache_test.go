@@ -0,0 +1,338 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCacher(t *testing.T) {
+	// not parallel, it changes the environment
+	defer os.Unsetenv(CACHE_BACKEND)
+	defer os.Unsetenv(CACHE_WRITE_MODE)
+	defer os.Unsetenv(REDIS_ADDR)
+	defer os.Unsetenv(REDIS_KEY_PREFIX)
+	defer os.Unsetenv(CACHE_SHARDS)
+	for backend, expected := range map[string]string{
+		"":                   "*cacher.shardedCache",
+		Cache_Backend_Memory: "*cacher.shardedCache",
+		Cache_Backend_Redis:  "*cacher.redisCache",
+		"memory,redis":       "*cacher.tieredCache",
+		"memory, memory":     "*cacher.tieredCache",
+	} {
+		require.NoError(t, os.Setenv(CACHE_BACKEND, backend))
+		c, err := newCacher()
+		require.NoError(t, err, backend)
+		require.Equal(t, expected, fmt.Sprintf("%T", c), backend)
+		require.NoError(t, c.(io.Closer).Close())
+	}
+
+	require.NoError(t, os.Setenv(CACHE_BACKEND, Cache_Backend_Memory))
+	require.NoError(t, os.Setenv(CACHE_SHARDS, "1"))
+	c, err := newCacher()
+	require.NoError(t, err)
+	require.Equal(t, "*cacher.cache", fmt.Sprintf("%T", c))
+	require.NoError(t, c.(io.Closer).Close())
+	for _, invalid := range []string{"0", "abc"} {
+		require.NoError(t, os.Setenv(CACHE_SHARDS, invalid))
+		_, err = newCacher()
+		require.Error(t, err, invalid)
+	}
+	require.NoError(t, os.Unsetenv(CACHE_SHARDS))
+
+	redis, err := test.NewRedisServer("")
+	require.NoError(t, err)
+	defer redis.Close()
+	require.NoError(t, os.Setenv(CACHE_BACKEND, "memory,redis"))
+	require.NoError(t, os.Setenv(CACHE_WRITE_MODE, Cache_Write_Behind))
+	require.NoError(t, os.Setenv(REDIS_ADDR, redis.Addr()))
+	c, err = newCacher()
+	require.NoError(t, err)
+	c.Set("key", "value")
+	// closing flushes the pending writes
+	require.NoError(t, c.(io.Closer).Close())
+	// the keys are namespaced in the shared redis
+	v, ok := redis.Get(Redis_Default_Prefix + "key")
+	require.True(t, ok)
+	require.Equal(t, "value", v)
+	require.NoError(t, os.Setenv(REDIS_KEY_PREFIX, ""))
+	c, err = newCacher()
+	require.NoError(t, err)
+	c.Set("key", "value")
+	require.NoError(t, c.(io.Closer).Close())
+	_, ok = redis.Get("key")
+	require.True(t, ok)
+
+	for _, invalid := range []struct{ backend, mode string }{
+		{backend: "unknown"},
+		{backend: "memory,unknown"},
+		{backend: "unknown,memory"},
+		{backend: "memory,memory,redis"},
+		{backend: "memory,redis", mode: "unknown"},
+	} {
+		require.NoError(t, os.Setenv(CACHE_BACKEND, invalid.backend))
+		require.NoError(t, os.Setenv(CACHE_WRITE_MODE, invalid.mode))
+		_, err = newCacher()
+		require.Error(t, err, invalid.backend)
+	}
+}
+
+func TestGetCachedStale(t *testing.T) {
+	t.Parallel()
+	id := 1011334
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newServer := func(t *testing.T, handler http.Handler, storedAt time.Time) *Server {
+		testServer, err := test.NewTestServer(handler)
+		require.NoError(t, err)
+		s := &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "",
+				marvel.WithScheme("http"),
+				marvel.WithRetryPolicy(marvel.RetryPolicy{MaxAttempts: 1}),
+			),
+			cacher: cacher.NewCacher(),
+			now:    func() time.Time { return now },
+		}
+		value, err := cacher.EncodeEntry([]byte(`{"id":1011334,"name":"stale"}`), storedAt)
+		require.NoError(t, err)
+		s.cacher.Set(buildCharacterInfoCacheKey(id), value)
+		return s
+	}
+	getName := func(t *testing.T, s *Server, status int) string {
+		req := httptest.NewRequest(http.MethodGet, "/characters/"+strconv.Itoa(id), nil)
+		rec := httptest.NewRecorder()
+		s.GetCharacterInfo(rec, mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)}))
+		require.Equal(t, status, rec.Code)
+		var character marvel.MarvelCharacter
+		_ = json.NewDecoder(rec.Body).Decode(&character)
+		return character.Name
+	}
+	cachedName := func(s *Server) string {
+		cached, ok := s.lookupCached(buildCharacterInfoCacheKey(id), func() interface{} {
+			return new(marvel.MarvelCharacter)
+		})
+		if !ok || cached.missing {
+			return ""
+		}
+		return cached.value.(*marvel.MarvelCharacter).Name
+	}
+	unavailable := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	t.Run("fresh", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, unavailable, now.Add(-Detail_Cache_TTL+time.Minute))
+		require.Equal(t, "stale", getName(t, s, http.StatusOK))
+		require.Zero(t, s.marvelAPI.Stats().Requests)
+	})
+	t.Run("revalidate", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, test.NewMockHandler(test.SampleAllData1stCall), now.Add(-Detail_Cache_TTL-time.Minute))
+		// the stale value is served right away, the refresh runs in background
+		require.Equal(t, "stale", getName(t, s, http.StatusOK))
+		require.Eventually(t, func() bool {
+			return cachedName(s) == "1-D Man"
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, "1-D Man", getName(t, s, http.StatusOK))
+	})
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, test.NewMockHandler(test.SampleAllData1stCall),
+			now.Add(-Detail_Cache_TTL-Stale_While_Revalidate))
+		require.Equal(t, "1-D Man", getName(t, s, http.StatusOK))
+	})
+	t.Run("stale_on_error", func(t *testing.T) {
+		t.Parallel()
+		storedAt := now.Add(-Detail_Cache_TTL - 2*Stale_While_Revalidate)
+		s := newServer(t, unavailable, storedAt)
+		getName(t, s, http.StatusServiceUnavailable)
+
+		s = newServer(t, unavailable, storedAt)
+		s.serveStaleOnError = true
+		require.Equal(t, "stale", getName(t, s, http.StatusOK))
+	})
+	t.Run("not_found", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, http.NotFoundHandler(), now.Add(-Detail_Cache_TTL-2*Stale_While_Revalidate))
+		s.serveStaleOnError = true
+		// a removed character isn't served stale
+		getName(t, s, http.StatusNotFound)
+	})
+}
+
+func TestGetCachedCanceled(t *testing.T) {
+	t.Parallel()
+	s := &Server{cacher: cacher.NewCacher()}
+	var fetches int32
+	started, release := make(chan struct{}), make(chan struct{})
+	fetch := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&fetches, 1)
+		close(started)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-release:
+			return &[]int{1, 2}, nil
+		}
+	}
+	newValue := func() interface{} {
+		return new([]int)
+	}
+	get := func(ctx context.Context, errs chan<- error) {
+		v, err := s.getCached(ctx, Characters_Cache_Key, time.Hour, newValue, fetch)
+		if err == nil && !reflect.DeepEqual(&[]int{1, 2}, v) {
+			err = fmt.Errorf("unexpected value %v", v)
+		}
+		errs <- err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled, waiting := make(chan error, 1), make(chan error, 1)
+	go get(ctx, canceled)
+	<-started
+	go get(context.Background(), waiting)
+	// let the second call join the fetch
+	time.Sleep(10 * time.Millisecond)
+
+	// the client which started the fetch left, only its call returns
+	cancel()
+	require.ErrorIs(t, <-canceled, context.Canceled)
+	select {
+	case err := <-waiting:
+		t.Fatalf("the merged call returned %v before the fetch", err)
+	default:
+	}
+	close(release)
+	require.NoError(t, <-waiting)
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	_, ok := s.cacher.Get(Characters_Cache_Key)
+	require.True(t, ok)
+}
+
+func TestGetCachedDerived(t *testing.T) {
+	t.Parallel()
+	testServer, err := test.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	require.NoError(t, err)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{
+		marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "",
+			marvel.WithScheme("http"),
+			marvel.WithRetryPolicy(marvel.RetryPolicy{MaxAttempts: 1}),
+		),
+		cacher: cacher.NewCacher(),
+		now:    func() time.Time { return now },
+	}
+	storedAt := now
+	s.setCached(Characters_Summary_Cache_Key, &[]*marvel.MarvelCharacter{{ID: 1}, {ID: 2}}, Character_List_Cache_TTL)
+	s.setCached(Characters_Cache_Key, &[]int{1}, Character_List_Cache_TTL)
+	// both lists are stale, marvel is unavailable
+	now = now.Add(Character_List_Cache_TTL + time.Minute)
+
+	rec := getCharacterList(s, "/characters", http.Header{})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "[1]\n", rec.Body.String())
+	// the list is refreshed from the stale records in background
+	require.Eventually(t, func() bool {
+		return s.cacher.(cacher.StatsReporter).Stats().Sets == 3
+	}, time.Second, time.Millisecond)
+	cached, ok := s.lookupCached(Characters_Cache_Key, func() interface{} {
+		return new([]int)
+	})
+	require.True(t, ok)
+	require.Equal(t, &[]int{1, 2}, cached.value)
+	// the refreshed list isn't fresher than the records it is derived from
+	require.True(t, storedAt.Equal(cached.storedAt))
+}
+
+func TestCachedValues(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		codec cacher.Codec
+		// summaryCodec encodes the character records
+		summaryCodec string
+	}{
+		{codec: cacher.JSONCodec, summaryCodec: cacher.Codec_JSON},
+		{codec: cacher.GobCodec, summaryCodec: cacher.Codec_Gob},
+		// the records fall back to json
+		{codec: cacher.BinaryCodec, summaryCodec: cacher.Codec_JSON},
+	} {
+		tt := tt
+		t.Run(tt.codec.Name(), func(t *testing.T) {
+			t.Parallel()
+			testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+			require.NoError(t, err)
+			s := &Server{
+				marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+				cacher:    cacher.NewCacher(),
+				codec:     tt.codec,
+				values:    cacher.NewValueCache(Cache_Decoded_Max_Entries),
+			}
+			rec := httptest.NewRecorder()
+			s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
+			require.JSONEq(t, "[1011334,1011335,1011336]", rec.Body.String())
+			for key, codec := range map[string]string{
+				Characters_Cache_Key:         tt.codec.Name(),
+				Characters_Summary_Cache_Key: tt.summaryCodec,
+			} {
+				v, ok := s.cacher.Get(key)
+				require.True(t, ok)
+				entry, ok := cacher.DecodeEntry(v)
+				require.True(t, ok)
+				require.Equal(t, codec, entry.Codec, key)
+			}
+
+			newList := func() interface{} { return new([]int) }
+			cached, ok := s.lookupCached(Characters_Cache_Key, newList)
+			require.True(t, ok)
+			again, ok := s.lookupCached(Characters_Cache_Key, newList)
+			require.True(t, ok)
+			// the hit isn't decoded again
+			require.Same(t, cached.value, again.value)
+
+			// a server sharing the cache decodes the stored values
+			other := &Server{cacher: s.cacher, codec: tt.codec}
+			decoded, ok := other.lookupCached(Characters_Cache_Key, newList)
+			require.True(t, ok)
+			require.NotSame(t, cached.value, decoded.value)
+			require.Equal(t, cached.value, decoded.value)
+			records, ok := other.lookupCached(Characters_Summary_Cache_Key, func() interface{} {
+				return new([]*marvel.MarvelCharacter)
+			})
+			require.True(t, ok)
+			require.Len(t, *records.value.(*[]*marvel.MarvelCharacter), 3)
+		})
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	// not parallel, it changes the environment
+	defer os.Unsetenv(CACHE_CODEC)
+	for name, expected := range map[string]cacher.Codec{
+		"":                  cacher.JSONCodec,
+		cacher.Codec_Gob:    cacher.GobCodec,
+		cacher.Codec_Binary: cacher.BinaryCodec,
+	} {
+		require.NoError(t, os.Setenv(CACHE_CODEC, name))
+		codec, err := newCodec()
+		require.NoError(t, err, name)
+		require.Equal(t, expected, codec, name)
+	}
+	require.NoError(t, os.Setenv(CACHE_CODEC, "unknown"))
+	_, err := newCodec()
+	require.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Comics_Cache_Key           = "comics"
+	Comic_Cache_Key            = "comic"
+	Character_Comics_Cache_Key = "character_comics"
+)
+
+// comics is the comics resource of marvel api
+func (s *Server) comics() marvelResource {
+	return marvelResource{
+		name:              "comic",
+		listCacheKey:      Comics_Cache_Key,
+		detailCacheKey:    Comic_Cache_Key,
+		characterCacheKey: Character_Comics_Cache_Key,
+		newList: func() interface{} {
+			return new(marvel.ComicList)
+		},
+		newDetail: func() interface{} {
+			return new(marvel.Comic)
+		},
+		parseFilter: func(query url.Values) (resourceFilter, error) {
+			return parseComicFilter(query)
+		},
+		list: func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListComicsWithContext(ctx, filter.(marvel.ComicFilter))
+		},
+		get: func(ctx context.Context, id int) (interface{}, error) {
+			return s.marvelAPI.GetComicWithContext(ctx, id)
+		},
+		listByCharacter: func(ctx context.Context, charID int, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCharacterComicsWithContext(ctx, charID, filter.(marvel.ComicFilter))
+		},
+	}
+}
+
+// ListComics responds a page of comics matching the marvel filters in query parameters
+func (s *Server) ListComics(w http.ResponseWriter, r *http.Request) {
+	s.listResource(w, r, s.comics())
+}
+
+// GetComic responds the comic by id
+func (s *Server) GetComic(w http.ResponseWriter, r *http.Request) {
+	s.getResource(w, r, s.comics())
+}
+
+// ListCharacterComics responds a page of comics featuring the character
+func (s *Server) ListCharacterComics(w http.ResponseWriter, r *http.Request) {
+	s.listCharacterResource(w, r, s.comics())
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Creators_Cache_Key           = "creators"
+	Creator_Cache_Key            = "creator"
+	Creator_Comics_Cache_Key     = "creator_comics"
+	Creator_Characters_Cache_Key = "creator_characters"
+
+	// MaxCreatorComicPages caps the comic pages scanned to derive the characters of a creator
+	MaxCreatorComicPages = 5
+)
+
+// CreatorCharacter is a character a creator has worked on
+type CreatorCharacter struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Comics is the number of scanned comics of the creator featuring the character
+	Comics int `json:"comics"`
+}
+
+// CreatorCharacters is the set of characters a creator has worked on
+type CreatorCharacters struct {
+	CreatorID     int `json:"creatorId"`
+	ComicsTotal   int `json:"comicsTotal"`
+	ComicsScanned int `json:"comicsScanned"`
+	// Complete is false when the creator has too many comics to scan them all, or the daily quota is running out
+	Complete   bool                `json:"complete"`
+	Characters []*CreatorCharacter `json:"characters"`
+}
+
+// creators is the creators resource of marvel api, creators aren't listed by character
+func (s *Server) creators() marvelResource {
+	return marvelResource{
+		name:           "creator",
+		listCacheKey:   Creators_Cache_Key,
+		detailCacheKey: Creator_Cache_Key,
+		newList: func() interface{} {
+			return new(marvel.CreatorList)
+		},
+		newDetail: func() interface{} {
+			return new(marvel.Creator)
+		},
+		parseFilter: func(query url.Values) (resourceFilter, error) {
+			return parseCreatorFilter(query)
+		},
+		list: func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCreatorsWithContext(ctx, filter.(marvel.CreatorFilter))
+		},
+		get: func(ctx context.Context, id int) (interface{}, error) {
+			return s.marvelAPI.GetCreatorWithContext(ctx, id)
+		},
+	}
+}
+
+// ListCreators responds a page of creators matching the marvel filters in query parameters
+func (s *Server) ListCreators(w http.ResponseWriter, r *http.Request) {
+	s.listResource(w, r, s.creators())
+}
+
+// GetCreator responds the creator by id
+func (s *Server) GetCreator(w http.ResponseWriter, r *http.Request) {
+	s.getResource(w, r, s.creators())
+}
+
+// ListCreatorComics responds a page of comics the creator worked on
+func (s *Server) ListCreatorComics(w http.ResponseWriter, r *http.Request) {
+	s.listRelatedResource(w, r, s.comics(), "creator", Creator_Comics_Cache_Key,
+		func(ctx context.Context, creatorID int, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCreatorComicsWithContext(ctx, creatorID, filter.(marvel.ComicFilter))
+		})
+}
+
+// ListCreatorCharacters responds the characters featured in the comics of the creator
+func (s *Server) ListCreatorCharacters(w http.ResponseWriter, r *http.Request) {
+	creatorID, ok := parseID(w, r, "invalid creator id")
+	if !ok {
+		return
+	}
+	cacheKey := Creator_Characters_Cache_Key + "_" + strconv.Itoa(creatorID)
+	s.serveCached(w, r, cacheKey, List_Cache_TTL, func() interface{} {
+		return new(CreatorCharacters)
+	}, func(ctx context.Context) (interface{}, error) {
+		return s.deriveCreatorCharacters(ctx, creatorID)
+	})
+}
+
+// getCreatorComics gets a page of creator comics through the cache
+func (s *Server) getCreatorComics(ctx context.Context, creatorID int, filter marvel.ComicFilter) (*marvel.ComicList, error) {
+	cacheKey := Creator_Comics_Cache_Key + "_" + strconv.Itoa(creatorID) + "_" + filter.Values().Encode()
+	v, err := s.getCached(ctx, cacheKey, List_Cache_TTL, func() interface{} {
+		return new(marvel.ComicList)
+	}, func(ctx context.Context) (interface{}, error) {
+		return s.marvelAPI.ListCreatorComicsWithContext(ctx, creatorID, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*marvel.ComicList), nil
+}
+
+// deriveCreatorCharacters scans the cached comic pages of the creator and collects their characters
+// the pages are fetched with a low priority, the scan stops at the reserve of the daily quota kept for user requests
+func (s *Server) deriveCreatorCharacters(ctx context.Context, creatorID int) (*CreatorCharacters, error) {
+	ctx = marvel.WithLowPriority(ctx)
+	result := &CreatorCharacters{
+		CreatorID: creatorID,
+	}
+	characters := make(map[int]*CreatorCharacter)
+	filter := marvel.ComicFilter{
+		ListOptions: marvel.ListOptions{
+			Limit: Marvel_Max_Limit,
+		},
+	}
+	for page := 0; page < MaxCreatorComicPages; page++ {
+		filter.Offset = page * Marvel_Max_Limit
+		list, err := s.getCreatorComics(ctx, creatorID, filter)
+		if err != nil {
+			if page > 0 && errors.Is(err, marvel.ErrQuotaExceeded) {
+				// log error
+				log.Println("partial characters of creator", creatorID, err)
+				break
+			}
+			return nil, err
+		}
+		result.ComicsTotal = list.Total
+		result.ComicsScanned += len(list.Results)
+		for _, comic := range list.Results {
+			for _, item := range comic.Characters.Items {
+				id, err := item.ID()
+				if err != nil {
+					// log error
+					log.Println("invalid character of comic", comic.ID, err)
+					continue
+				}
+				character, ok := characters[id]
+				if !ok {
+					character = &CreatorCharacter{
+						ID:   id,
+						Name: item.Name,
+					}
+					characters[id] = character
+				}
+				character.Comics++
+			}
+		}
+		if len(list.Results) == 0 || filter.Offset+len(list.Results) >= list.Total {
+			result.Complete = true
+			break
+		}
+	}
+	result.Characters = make([]*CreatorCharacter, 0, len(characters))
+	for _, character := range characters {
+		result.Characters = append(result.Characters, character)
+	}
+	sort.Slice(result.Characters, func(i, j int) bool {
+		if result.Characters[i].Comics != result.Characters[j].Comics {
+			return result.Characters[i].Comics > result.Characters[j].Comics
+		}
+		return result.Characters[i].ID < result.Characters[j].ID
+	})
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCreatorCharacters(t *testing.T) {
+	t.Parallel()
+	newServer := func(t *testing.T, json string, opts ...marvel.Option) *Server {
+		testServer, err := test.NewTestServer(test.NewMockHandler(json))
+		require.NoError(t, err)
+		opts = append(opts, marvel.WithScheme("http"))
+		return &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", opts...),
+			cacher:    cacher.NewCacher(),
+		}
+	}
+	t.Run("single_page", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, test.SampleComicJson)
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/creators/1133/characters", nil)
+			s.ListCreatorCharacters(rec, mux.SetURLVars(req, map[string]string{"id": "1133"}))
+			require.Equal(t, http.StatusOK, rec.Code)
+			var result CreatorCharacters
+			err := json.NewDecoder(rec.Body).Decode(&result)
+			require.NoError(t, err)
+			require.True(t, result.Complete)
+			require.Equal(t, 1, result.ComicsScanned)
+			require.Equal(t, []*CreatorCharacter{
+				{ID: 1009165, Name: "Avengers", Comics: 1},
+				{ID: 1011334, Name: "3-D Man", Comics: 1},
+			}, result.Characters)
+		}
+		require.Equal(t, uint64(1), s.marvelAPI.Stats().Requests)
+	})
+	t.Run("multiple_pages", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, strings.Replace(test.SampleComicJson, `"total": 1,`, `"total": 2,`, 1))
+		// the mock responds 1 comic per page, the 2nd page is fetched at offset 100
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/creators/1133/characters", nil)
+		s.ListCreatorCharacters(rec, mux.SetURLVars(req, map[string]string{"id": "1133"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result CreatorCharacters
+		err := json.NewDecoder(rec.Body).Decode(&result)
+		require.NoError(t, err)
+		require.True(t, result.Complete)
+		require.Equal(t, 2, result.ComicsTotal)
+		require.Equal(t, 2, result.ComicsScanned)
+		require.Equal(t, 2, result.Characters[0].Comics)
+		_, ok := s.cacher.Get(Creator_Comics_Cache_Key + "_1133_limit=100&offset=100")
+		require.True(t, ok)
+	})
+	t.Run("quota_reserve", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, strings.Replace(test.SampleComicJson, `"total": 1,`, `"total": 2,`, 1),
+			marvel.WithDailyQuota(3, 2))
+		// the scan stops at the reserve, the characters of the scanned pages are responded
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/creators/1133/characters", nil)
+		s.ListCreatorCharacters(rec, mux.SetURLVars(req, map[string]string{"id": "1133"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result CreatorCharacters
+		err := json.NewDecoder(rec.Body).Decode(&result)
+		require.NoError(t, err)
+		require.False(t, result.Complete)
+		require.Equal(t, 2, result.ComicsTotal)
+		require.Equal(t, 1, result.ComicsScanned)
+		require.Equal(t, uint64(1), s.marvelAPI.Stats().Requests)
+		// the reserve is left to user requests
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/creators/1133/comics?offset=100", nil)
+		s.ListCreatorComics(rec, mux.SetURLVars(req, map[string]string{"id": "1133"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, uint64(2), s.marvelAPI.Stats().Requests)
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+// problem is a RFC 7807 problem details body
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// writeProblem responds a problem details body with status
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(&problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+	// log error
+	if err != nil {
+		log.Println("encode error", err)
+	}
+}
+
+// writeError maps err to its http status and responds a problem details body
+// the details of upstream failures are logged but never sent to the client
+func writeError(w http.ResponseWriter, err error) {
+	var (
+		status int
+		detail string
+	)
+	switch {
+	case errors.Is(err, marvel.ErrNotFound):
+		status, detail = http.StatusNotFound, "resource not found"
+	case errors.Is(err, marvel.ErrInvalidParameter):
+		status, detail = http.StatusBadRequest, "invalid parameter"
+	case errors.Is(err, marvel.ErrRateLimited):
+		status, detail = http.StatusTooManyRequests, "marvel api rate limit reached, try again later"
+		var apiErr *marvel.Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+		}
+	case errors.Is(err, marvel.ErrUnauthorized), errors.Is(err, marvel.ErrMalformedResponse):
+		status, detail = http.StatusBadGateway, "invalid response from marvel api"
+	case errors.Is(err, marvel.ErrUpstreamUnavailable),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		status, detail = http.StatusServiceUnavailable, "marvel api is unavailable"
+	default:
+		status, detail = http.StatusInternalServerError, "internal server error"
+	}
+	// log error
+	log.Println("request error", err)
+	writeProblem(w, status, detail)
+}
+
+// writeJSON responds v encoded in json with status 200
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(v)
+	// log error
+	if err != nil {
+		log.Println("encode error", err)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not_found", fmt.Errorf("no character: %w", marvel.ErrNotFound), http.StatusNotFound},
+		{"invalid_parameter", marvel.ErrInvalidParameter, http.StatusBadRequest},
+		{"rate_limited", marvel.ErrRateLimited, http.StatusTooManyRequests},
+		{"quota_exceeded", marvel.ErrQuotaExceeded, http.StatusTooManyRequests},
+		{"unauthorized", marvel.ErrUnauthorized, http.StatusBadGateway},
+		{"malformed", marvel.ErrMalformedResponse, http.StatusBadGateway},
+		{"unavailable", marvel.ErrUpstreamUnavailable, http.StatusServiceUnavailable},
+		{"timeout", context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{"unknown", errors.New("unknown"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			writeError(rec, c.err)
+			require.Equal(t, c.status, rec.Code)
+			require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var p problem
+			err := json.NewDecoder(rec.Body).Decode(&p)
+			require.NoError(t, err)
+			require.Equal(t, c.status, p.Status)
+			require.Equal(t, http.StatusText(c.status), p.Title)
+			require.NotEmpty(t, p.Detail)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Events_Cache_Key           = "events"
+	Event_Cache_Key            = "event"
+	Character_Events_Cache_Key = "character_events"
+)
+
+// events is the events resource of marvel api
+func (s *Server) events() marvelResource {
+	return marvelResource{
+		name:              "event",
+		listCacheKey:      Events_Cache_Key,
+		detailCacheKey:    Event_Cache_Key,
+		characterCacheKey: Character_Events_Cache_Key,
+		newList: func() interface{} {
+			return new(marvel.EventList)
+		},
+		newDetail: func() interface{} {
+			return new(marvel.Event)
+		},
+		parseFilter: func(query url.Values) (resourceFilter, error) {
+			return parseEventFilter(query)
+		},
+		list: func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListEventsWithContext(ctx, filter.(marvel.EventFilter))
+		},
+		get: func(ctx context.Context, id int) (interface{}, error) {
+			return s.marvelAPI.GetEventWithContext(ctx, id)
+		},
+		listByCharacter: func(ctx context.Context, charID int, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCharacterEventsWithContext(ctx, charID, filter.(marvel.EventFilter))
+		},
+	}
+}
+
+// ListEvents responds a page of events matching the marvel filters in query parameters
+func (s *Server) ListEvents(w http.ResponseWriter, r *http.Request) {
+	s.listResource(w, r, s.events())
+}
+
+// GetEvent responds the event by id
+func (s *Server) GetEvent(w http.ResponseWriter, r *http.Request) {
+	s.getResource(w, r, s.events())
+}
+
+// ListCharacterEvents responds a page of events of the character
+func (s *Server) ListCharacterEvents(w http.ResponseWriter, r *http.Request) {
+	s.listCharacterResource(w, r, s.events())
+}
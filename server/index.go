@@ -0,0 +1,98 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+// characterIndex is an in-memory index of the character names for search
+// it is only used once it has been built from the full character list
+type characterIndex struct {
+	lock    sync.RWMutex
+	ready   bool
+	entries []indexEntry
+}
+
+type indexEntry struct {
+	// name is the lower case character name, entries are sorted by it
+	name      string
+	character *marvel.MarvelCharacter
+}
+
+// Build replaces the index content with characters
+func (idx *characterIndex) Build(characters []*marvel.MarvelCharacter) {
+	entries := make([]indexEntry, 0, len(characters))
+	for _, character := range characters {
+		entries = append(entries, indexEntry{
+			name:      strings.ToLower(character.Name),
+			character: character,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].name != entries[j].name {
+			return entries[i].name < entries[j].name
+		}
+		return entries[i].character.ID < entries[j].character.ID
+	})
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	idx.entries = entries
+	idx.ready = true
+}
+
+// Reset empties the index, it isn't used until it is built again
+func (idx *characterIndex) Reset() {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	idx.entries = nil
+	idx.ready = false
+}
+
+// Ready tells whether the index has been built
+func (idx *characterIndex) Ready() bool {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.ready
+}
+
+// Search finds the characters whose name equals name (if set) and starts with prefix (if set)
+// matching is case insensitive like marvel api, it returns the total count and the page at offset
+func (idx *characterIndex) Search(name, prefix string, offset, limit int) ([]*marvel.MarvelCharacter, int) {
+	name = strings.ToLower(name)
+	prefix = strings.ToLower(prefix)
+	if name != "" {
+		if !strings.HasPrefix(name, prefix) {
+			return nil, 0
+		}
+		// an exact name is the narrowest prefix
+		prefix = name
+	}
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	start := sort.Search(len(idx.entries), func(i int) bool {
+		return idx.entries[i].name >= prefix
+	})
+	end := start
+	for end < len(idx.entries) && strings.HasPrefix(idx.entries[end].name, prefix) {
+		if name != "" && idx.entries[end].name != name {
+			break
+		}
+		end++
+	}
+	total := end - start
+	start += offset
+	if start > end {
+		start = end
+	}
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	results := make([]*marvel.MarvelCharacter, 0, end-start)
+	for _, entry := range idx.entries[start:end] {
+		results = append(results, entry.character)
+	}
+	return results, total
+}
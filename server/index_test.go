@@ -0,0 +1,59 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharacterIndex(t *testing.T) {
+	t.Parallel()
+	var idx characterIndex
+	require.False(t, idx.Ready())
+	idx.Build([]*marvel.MarvelCharacter{
+		{ID: 1, Name: "Spider-Man"},
+		{ID: 2, Name: "Spider-Woman"},
+		{ID: 3, Name: "Hulk"},
+		{ID: 4, Name: "spider-man"},
+		{ID: 5, Name: "Spiderling"},
+	})
+	require.True(t, idx.Ready())
+	ids := func(characters []*marvel.MarvelCharacter) (result []int) {
+		for _, c := range characters {
+			result = append(result, c.ID)
+		}
+		return
+	}
+	t.Run("prefix", func(t *testing.T) {
+		results, total := idx.Search("", "SPIDER-", 0, 0)
+		require.Equal(t, 3, total)
+		require.Equal(t, []int{1, 4, 2}, ids(results))
+	})
+	t.Run("prefix_paging", func(t *testing.T) {
+		results, total := idx.Search("", "spider", 1, 2)
+		require.Equal(t, 4, total)
+		require.Equal(t, []int{4, 2}, ids(results))
+		results, total = idx.Search("", "spider", 10, 2)
+		require.Equal(t, 4, total)
+		require.Empty(t, results)
+	})
+	t.Run("name", func(t *testing.T) {
+		results, total := idx.Search("spider-man", "", 0, 0)
+		require.Equal(t, 2, total)
+		require.Equal(t, []int{1, 4}, ids(results))
+	})
+	t.Run("name_and_prefix", func(t *testing.T) {
+		results, total := idx.Search("Hulk", "hu", 0, 0)
+		require.Equal(t, 1, total)
+		require.Equal(t, []int{3}, ids(results))
+		results, total = idx.Search("Hulk", "spider", 0, 0)
+		require.Zero(t, total)
+		require.Empty(t, results)
+	})
+	t.Run("no_match", func(t *testing.T) {
+		results, total := idx.Search("", "zzz", 0, 0)
+		require.Zero(t, total)
+		require.Empty(t, results)
+	})
+}
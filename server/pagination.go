@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	// Page_Default_Limit is the page size of the paginated lists when limit is not set
+	Page_Default_Limit = 100
+	// Page_Max_Limit is the biggest page size of the paginated lists
+	Page_Max_Limit = 1000
+)
+
+// pageParams is the requested window of a paginated list
+type pageParams struct {
+	offset int
+	limit  int
+}
+
+// parsePageParams parses the limit, offset and cursor query parameters
+// paginated is false when none of them is set
+func parsePageParams(query url.Values) (params pageParams, paginated bool, err error) {
+	cursor := query.Get("cursor")
+	if cursor == "" && query.Get("limit") == "" && query.Get("offset") == "" {
+		return params, false, nil
+	}
+	params.limit = Page_Default_Limit
+	if cursor != "" {
+		if params, err = decodeCursor(cursor); err != nil {
+			return params, true, err
+		}
+	} else if params.offset, err = queryInt(query, "offset"); err != nil {
+		return params, true, err
+	}
+	if query.Get("limit") != "" {
+		if params.limit, err = queryInt(query, "limit"); err != nil {
+			return params, true, err
+		}
+	}
+	if params.offset < 0 {
+		return params, true, fmt.Errorf("offset must not be negative")
+	}
+	if params.limit <= 0 || params.limit > Page_Max_Limit {
+		return params, true, fmt.Errorf("limit must be between 1 and %d", Page_Max_Limit)
+	}
+	return params, true, nil
+}
+
+// encodeCursor encodes a page window as an opaque cursor
+func encodeCursor(params pageParams) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(params.offset) + ":" + strconv.Itoa(params.limit)))
+}
+
+func decodeCursor(cursor string) (params pageParams, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return params, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 2 {
+		return params, fmt.Errorf("invalid cursor")
+	}
+	if params.offset, err = strconv.Atoi(parts[0]); err != nil {
+		return params, fmt.Errorf("invalid cursor")
+	}
+	if params.limit, err = strconv.Atoi(parts[1]); err != nil {
+		return params, fmt.Errorf("invalid cursor")
+	}
+	return params, nil
+}
+
+// CharacterIDPage is a page of the character id list
+type CharacterIDPage struct {
+	Total      int    `json:"total"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	Results    []int  `json:"results"`
+}
+
+// CharacterSummaryPage is a page of the character summary list
+type CharacterSummaryPage struct {
+	Total      int                       `json:"total"`
+	Offset     int                       `json:"offset"`
+	Limit      int                       `json:"limit"`
+	NextCursor string                    `json:"nextCursor,omitempty"`
+	PrevCursor string                    `json:"prevCursor,omitempty"`
+	Results    []*marvel.MarvelCharacter `json:"results"`
+}
+
+// paginate cuts the page out of list, sets the Link header and returns the page envelope
+func paginate(w http.ResponseWriter, r *http.Request, list []int, params pageParams) *CharacterIDPage {
+	page := &CharacterIDPage{
+		Total:  len(list),
+		Offset: params.offset,
+		Limit:  params.limit,
+	}
+	start, end := pageBounds(len(list), params)
+	page.Results = list[start:end]
+	page.PrevCursor, page.NextCursor = setPageLinks(w, r, len(list), params)
+	return page
+}
+
+// paginateSummaries is paginate for the character summary list
+func paginateSummaries(w http.ResponseWriter, r *http.Request,
+	list []*marvel.MarvelCharacter,
+	params pageParams,
+) *CharacterSummaryPage {
+	page := &CharacterSummaryPage{
+		Total:  len(list),
+		Offset: params.offset,
+		Limit:  params.limit,
+	}
+	start, end := pageBounds(len(list), params)
+	page.Results = list[start:end]
+	page.PrevCursor, page.NextCursor = setPageLinks(w, r, len(list), params)
+	return page
+}
+
+// setPageLinks sets the first, prev, next and last Link header of the page
+// and returns the prev and next cursors, they are empty if there is no such page
+func setPageLinks(w http.ResponseWriter, r *http.Request, total int, params pageParams) (prevCursor, nextCursor string) {
+	var links []string
+	link := func(rel string, p pageParams) string {
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, pageQuery(r, p), rel)
+	}
+	links = append(links, link("first", pageParams{offset: 0, limit: params.limit}))
+	if params.offset > 0 {
+		prev := pageParams{offset: params.offset - params.limit, limit: params.limit}
+		if prev.offset < 0 {
+			prev.offset = 0
+		}
+		prevCursor = encodeCursor(prev)
+		links = append(links, link("prev", prev))
+	}
+	_, end := pageBounds(total, params)
+	if end < total {
+		next := pageParams{offset: end, limit: params.limit}
+		nextCursor = encodeCursor(next)
+		links = append(links, link("next", next))
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / params.limit * params.limit
+	}
+	links = append(links, link("last", pageParams{offset: last, limit: params.limit}))
+	w.Header().Set("Link", strings.Join(links, ", "))
+	return prevCursor, nextCursor
+}
+
+// pageQuery is the query of the request with the page window replaced by the cursor of p
+func pageQuery(r *http.Request, p pageParams) string {
+	query := r.URL.Query()
+	query.Del("limit")
+	query.Del("offset")
+	query.Set("cursor", encodeCursor(p))
+	return query.Encode()
+}
+
+func pageBounds(total int, params pageParams) (start, end int) {
+	start = params.offset
+	if start > total {
+		start = total
+	}
+	end = start + params.limit
+	if end > total {
+		end = total
+	}
+	return start, end
+}
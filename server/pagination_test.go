@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageParams(t *testing.T) {
+	t.Parallel()
+	t.Run("not_paginated", func(t *testing.T) {
+		t.Parallel()
+		_, paginated, err := parsePageParams(url.Values{})
+		require.NoError(t, err)
+		require.False(t, paginated)
+	})
+	t.Run("limit_offset", func(t *testing.T) {
+		t.Parallel()
+		params, paginated, err := parsePageParams(url.Values{"offset": {"10"}})
+		require.NoError(t, err)
+		require.True(t, paginated)
+		require.Equal(t, pageParams{offset: 10, limit: Page_Default_Limit}, params)
+	})
+	t.Run("cursor", func(t *testing.T) {
+		t.Parallel()
+		cursor := encodeCursor(pageParams{offset: 20, limit: 5})
+		params, paginated, err := parsePageParams(url.Values{"cursor": {cursor}, "offset": {"1"}})
+		require.NoError(t, err)
+		require.True(t, paginated)
+		require.Equal(t, pageParams{offset: 20, limit: 5}, params)
+	})
+	for _, q := range []string{"limit=0", "limit=1001", "offset=-1", "offset=abc", "cursor=invalid", "cursor=YTpi"} {
+		q := q
+		t.Run(q, func(t *testing.T) {
+			t.Parallel()
+			query, err := url.ParseQuery(q)
+			require.NoError(t, err)
+			_, _, err = parsePageParams(query)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestGetListCharactersPaginated(t *testing.T) {
+	t.Parallel()
+	s := &Server{
+		marvelAPI: marvel.NewAPI("", "", ""),
+		cacher:    cacher.NewCacher(),
+	}
+	s.cacher.Set(Characters_Cache_Key, "[0,1,2,3,4,5,6]")
+	get := func(t *testing.T, target string) (*httptest.ResponseRecorder, *CharacterIDPage) {
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		page := new(CharacterIDPage)
+		err := json.NewDecoder(rec.Body).Decode(page)
+		require.NoError(t, err)
+		return rec, page
+	}
+	t.Run("walk_pages", func(t *testing.T) {
+		t.Parallel()
+		rec, page := get(t, "/characters?limit=3")
+		require.Equal(t, 7, page.Total)
+		require.Equal(t, []int{0, 1, 2}, page.Results)
+		require.Empty(t, page.PrevCursor)
+		require.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+		require.Contains(t, rec.Header().Get("Link"), "</characters?cursor="+encodeCursor(pageParams{offset: 6, limit: 3})+`>; rel="last"`)
+
+		_, page = get(t, "/characters?cursor="+page.NextCursor)
+		require.Equal(t, []int{3, 4, 5}, page.Results)
+		require.Equal(t, encodeCursor(pageParams{offset: 0, limit: 3}), page.PrevCursor)
+
+		rec, page = get(t, "/characters?cursor="+page.NextCursor)
+		require.Equal(t, []int{6}, page.Results)
+		require.Empty(t, page.NextCursor)
+		require.NotContains(t, rec.Header().Get("Link"), `rel="next"`)
+	})
+	t.Run("out_of_range", func(t *testing.T) {
+		t.Parallel()
+		_, page := get(t, "/characters?offset=100")
+		require.Equal(t, 7, page.Total)
+		require.Empty(t, page.Results)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters?limit=-1", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
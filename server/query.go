@@ -0,0 +1,158 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+// Marvel_Max_Limit is the biggest page size marvel api accepts
+const Marvel_Max_Limit = 100
+
+// parseListOptions parses the paging and ordering query parameters
+func parseListOptions(query url.Values) (opts marvel.ListOptions, err error) {
+	if opts.Limit, err = queryInt(query, "limit"); err != nil {
+		return opts, err
+	}
+	if opts.Limit < 0 || opts.Limit > Marvel_Max_Limit {
+		return opts, fmt.Errorf("limit must be between 1 and %d", Marvel_Max_Limit)
+	}
+	if opts.Offset, err = queryInt(query, "offset"); err != nil {
+		return opts, err
+	}
+	if opts.Offset < 0 {
+		return opts, fmt.Errorf("offset must not be negative")
+	}
+	opts.OrderBy = query.Get("orderBy")
+	if opts.ModifiedSince, err = queryDate(query, "modifiedSince"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// parseComicFilter parses the comic filters from query parameters
+func parseComicFilter(query url.Values) (filter marvel.ComicFilter, err error) {
+	if filter.ListOptions, err = parseListOptions(query); err != nil {
+		return filter, err
+	}
+	filter.Format = query.Get("format")
+	filter.FormatType = query.Get("formatType")
+	if filter.NoVariants, err = queryBool(query, "noVariants"); err != nil {
+		return filter, err
+	}
+	filter.DateDescriptor = query.Get("dateDescriptor")
+	if v := query.Get("dateRange"); v != "" {
+		bounds := strings.Split(v, ",")
+		if len(bounds) != 2 {
+			return filter, fmt.Errorf("dateRange must be 2 dates separated by a comma")
+		}
+		if filter.DateFrom, err = parseDate("dateRange", bounds[0]); err != nil {
+			return filter, err
+		}
+		if filter.DateTo, err = parseDate("dateRange", bounds[1]); err != nil {
+			return filter, err
+		}
+	}
+	filter.Title = query.Get("title")
+	filter.TitleStartsWith = query.Get("titleStartsWith")
+	if filter.StartYear, err = queryInt(query, "startYear"); err != nil {
+		return filter, err
+	}
+	if filter.IssueNumber, err = queryInt(query, "issueNumber"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func queryInt(query url.Values, key string) (int, error) {
+	v := query.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+	return i, nil
+}
+
+func queryBool(query url.Values, key string) (bool, error) {
+	v := query.Get(key)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", key)
+	}
+	return b, nil
+}
+
+func queryDate(query url.Values, key string) (time.Time, error) {
+	v := query.Get(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return parseDate(key, v)
+}
+
+func parseDate(key, v string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date formatted as YYYY-MM-DD", key)
+	}
+	return t, nil
+}
+
+// parseSeriesFilter parses the series filters from query parameters
+func parseSeriesFilter(query url.Values) (filter marvel.SeriesFilter, err error) {
+	if filter.ListOptions, err = parseListOptions(query); err != nil {
+		return filter, err
+	}
+	filter.Title = query.Get("title")
+	filter.TitleStartsWith = query.Get("titleStartsWith")
+	if filter.StartYear, err = queryInt(query, "startYear"); err != nil {
+		return filter, err
+	}
+	filter.SeriesType = query.Get("seriesType")
+	filter.Contains = query.Get("contains")
+	return filter, nil
+}
+
+// parseEventFilter parses the event filters from query parameters
+func parseEventFilter(query url.Values) (filter marvel.EventFilter, err error) {
+	if filter.ListOptions, err = parseListOptions(query); err != nil {
+		return filter, err
+	}
+	filter.Name = query.Get("name")
+	filter.NameStartsWith = query.Get("nameStartsWith")
+	return filter, nil
+}
+
+// parseStoryFilter parses the story filters from query parameters
+func parseStoryFilter(query url.Values) (filter marvel.StoryFilter, err error) {
+	if filter.ListOptions, err = parseListOptions(query); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseCreatorFilter parses the creator filters from query parameters
+func parseCreatorFilter(query url.Values) (filter marvel.CreatorFilter, err error) {
+	if filter.ListOptions, err = parseListOptions(query); err != nil {
+		return filter, err
+	}
+	filter.FirstName = query.Get("firstName")
+	filter.MiddleName = query.Get("middleName")
+	filter.LastName = query.Get("lastName")
+	filter.Suffix = query.Get("suffix")
+	filter.NameStartsWith = query.Get("nameStartsWith")
+	filter.FirstNameStartsWith = query.Get("firstNameStartsWith")
+	filter.MiddleNameStartsWith = query.Get("middleNameStartsWith")
+	filter.LastNameStartsWith = query.Get("lastNameStartsWith")
+	return filter, nil
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseComicFilter(t *testing.T) {
+	t.Parallel()
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		query, err := url.ParseQuery("limit=50&offset=100&format=comic&noVariants=true&dateRange=2008-01-01,2008-12-31&titleStartsWith=Aven&issueNumber=14")
+		require.NoError(t, err)
+		filter, err := parseComicFilter(query)
+		require.NoError(t, err)
+		require.Equal(t, 50, filter.Limit)
+		require.Equal(t, 100, filter.Offset)
+		require.Equal(t, "comic", filter.Format)
+		require.True(t, filter.NoVariants)
+		require.Equal(t, time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC), filter.DateFrom)
+		require.Equal(t, time.Date(2008, 12, 31, 0, 0, 0, 0, time.UTC), filter.DateTo)
+		require.Equal(t, "Aven", filter.TitleStartsWith)
+		require.Equal(t, 14, filter.IssueNumber)
+	})
+	for _, q := range []string{
+		"limit=101",
+		"limit=abc",
+		"offset=-1",
+		"noVariants=maybe",
+		"dateRange=2008-01-01",
+		"dateRange=2008-01-01,tomorrow",
+		"issueNumber=1.5",
+		"modifiedSince=yesterday",
+	} {
+		q := q
+		t.Run(q, func(t *testing.T) {
+			t.Parallel()
+			query, err := url.ParseQuery(q)
+			require.NoError(t, err)
+			_, err = parseComicFilter(query)
+			require.Error(t, err)
+		})
+	}
+}
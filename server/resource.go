@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// resourceFilter is the marvel filter of a resource list
+type resourceFilter interface {
+	Values() url.Values
+}
+
+// marvelResource is a marvel resource served through the cache: its list, its detail by id and its list by character
+type marvelResource struct {
+	// name is the name of the resource in error messages
+	name           string
+	listCacheKey   string
+	detailCacheKey string
+	// characterCacheKey and listByCharacter are unset when the resource isn't listed by character
+	characterCacheKey string
+	newList           func() interface{}
+	newDetail         func() interface{}
+	// parseFilter parses the filter of the resource list from query parameters
+	parseFilter     func(query url.Values) (resourceFilter, error)
+	list            func(ctx context.Context, filter resourceFilter) (interface{}, error)
+	get             func(ctx context.Context, id int) (interface{}, error)
+	listByCharacter func(ctx context.Context, charID int, filter resourceFilter) (interface{}, error)
+}
+
+// listResource responds a page of the resource matching the marvel filters in query parameters
+func (s *Server) listResource(w http.ResponseWriter, r *http.Request, res marvelResource) {
+	s.serveResourceList(w, r, res, res.listCacheKey, res.list)
+}
+
+// getResource responds the resource by id
+func (s *Server) getResource(w http.ResponseWriter, r *http.Request, res marvelResource) {
+	id, ok := parseID(w, r, "invalid "+res.name+" id")
+	if !ok {
+		return
+	}
+	s.serveCached(w, r, res.detailCacheKey+"_"+strconv.Itoa(id), Detail_Cache_TTL, res.newDetail,
+		func(ctx context.Context) (interface{}, error) {
+			return res.get(ctx, id)
+		})
+}
+
+// listCharacterResource responds a page of the resource featuring the character
+func (s *Server) listCharacterResource(w http.ResponseWriter, r *http.Request, res marvelResource) {
+	s.listRelatedResource(w, r, res, "character", res.characterCacheKey, res.listByCharacter)
+}
+
+// listRelatedResource responds a page of the resource related to the parent resource by id, e.g. the comics of a creator
+// the pages are cached under cacheKey and the parent id
+func (s *Server) listRelatedResource(w http.ResponseWriter,
+	r *http.Request,
+	res marvelResource,
+	parent string,
+	cacheKey string,
+	list func(ctx context.Context, id int, filter resourceFilter) (interface{}, error),
+) {
+	id, ok := parseID(w, r, "invalid "+parent+" id")
+	if !ok {
+		return
+	}
+	s.serveResourceList(w, r, res, cacheKey+"_"+strconv.Itoa(id), func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+		return list(ctx, id, filter)
+	})
+}
+
+// serveResourceList parses the filter of the resource list, then responds the page of the filter cached under cacheKey
+func (s *Server) serveResourceList(w http.ResponseWriter,
+	r *http.Request,
+	res marvelResource,
+	cacheKey string,
+	fetch func(ctx context.Context, filter resourceFilter) (interface{}, error),
+) {
+	filter, err := res.parseFilter(r.URL.Query())
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.serveCached(w, r, cacheKey+"_"+filter.Values().Encode(), List_Cache_TTL, res.newList,
+		func(ctx context.Context) (interface{}, error) {
+			return fetch(ctx, filter)
+		})
+}
+
+// serveCached responds the value of cacheKey in json, it is fetched on cache miss
+func (s *Server) serveCached(w http.ResponseWriter,
+	r *http.Request,
+	cacheKey string,
+	ttl time.Duration,
+	newValue func() interface{},
+	fetch func(ctx context.Context) (interface{}, error),
+) {
+	v, err := s.getCached(r.Context(), cacheKey, ttl, newValue, fetch)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, v)
+}
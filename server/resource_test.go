@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarvelResources(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		json string
+		id   int
+		list func(s *Server) http.HandlerFunc
+		get  func(s *Server) http.HandlerFunc
+		// related lists the resource related to another resource at relatedPath
+		related     func(s *Server) http.HandlerFunc
+		relatedPath string
+	}{
+		{
+			name:        "comics",
+			json:        test.SampleComicJson,
+			id:          21366,
+			list:        func(s *Server) http.HandlerFunc { return s.ListComics },
+			get:         func(s *Server) http.HandlerFunc { return s.GetComic },
+			related:     func(s *Server) http.HandlerFunc { return s.ListCharacterComics },
+			relatedPath: "/characters/1011334/comics",
+		},
+		{
+			name:        "creators",
+			json:        test.SampleCreatorJson,
+			id:          1133,
+			list:        func(s *Server) http.HandlerFunc { return s.ListCreators },
+			get:         func(s *Server) http.HandlerFunc { return s.GetCreator },
+			related:     func(s *Server) http.HandlerFunc { return s.ListCreatorComics },
+			relatedPath: "/creators/1133/comics",
+		},
+		{
+			name:        "events",
+			json:        test.SampleEventJson,
+			id:          269,
+			list:        func(s *Server) http.HandlerFunc { return s.ListEvents },
+			get:         func(s *Server) http.HandlerFunc { return s.GetEvent },
+			related:     func(s *Server) http.HandlerFunc { return s.ListCharacterEvents },
+			relatedPath: "/characters/1011334/events",
+		},
+		{
+			name:        "series",
+			json:        test.SampleSeriesJson,
+			id:          1945,
+			list:        func(s *Server) http.HandlerFunc { return s.ListSeries },
+			get:         func(s *Server) http.HandlerFunc { return s.GetSeries },
+			related:     func(s *Server) http.HandlerFunc { return s.ListCharacterSeries },
+			relatedPath: "/characters/1011334/series",
+		},
+		{
+			name:        "stories",
+			json:        test.SampleStoryJson,
+			id:          19947,
+			list:        func(s *Server) http.HandlerFunc { return s.ListStories },
+			get:         func(s *Server) http.HandlerFunc { return s.GetStory },
+			related:     func(s *Server) http.HandlerFunc { return s.ListCharacterStories },
+			relatedPath: "/characters/1011334/stories",
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			testServer, err := test.NewTestServer(test.NewMockHandler(c.json))
+			require.NoError(t, err)
+			newServer := func() *Server {
+				return &Server{
+					marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+					cacher:    cacher.NewCacher(),
+				}
+			}
+			id := strconv.Itoa(c.id)
+			t.Run("list", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				for i := 0; i < 2; i++ {
+					rec := httptest.NewRecorder()
+					c.list(s)(rec, httptest.NewRequest(http.MethodGet, "/"+c.name+"?limit=10", nil))
+					require.Equal(t, http.StatusOK, rec.Code)
+					require.Equal(t, c.id, decodeResourceIDs(t, rec)[0])
+				}
+				require.Equal(t, uint64(1), s.marvelAPI.Stats().Requests)
+			})
+			t.Run("list_concurrent", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				n := 100
+				var wg sync.WaitGroup
+				wg.Add(n)
+				for i := 0; i < n; i++ {
+					go func() {
+						defer wg.Done()
+						rec := httptest.NewRecorder()
+						c.list(s)(rec, httptest.NewRequest(http.MethodGet, "/"+c.name+"?limit=20", nil))
+						require.Equal(t, http.StatusOK, rec.Code)
+						require.Equal(t, []int{c.id}, decodeResourceIDs(t, rec))
+					}()
+				}
+				wg.Wait()
+				// the concurrent requests are merged into one marvel call
+				require.Equal(t, uint64(1), s.marvelAPI.Stats().Requests)
+			})
+			t.Run("list_invalid_filter", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				rec := httptest.NewRecorder()
+				c.list(s)(rec, httptest.NewRequest(http.MethodGet, "/"+c.name+"?offset=-1", nil))
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Zero(t, s.marvelAPI.Stats().Requests)
+			})
+			t.Run("get", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/"+c.name+"/"+id, nil)
+				c.get(s)(rec, mux.SetURLVars(req, map[string]string{"id": id}))
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, c.id, decodeResourceID(t, rec))
+			})
+			t.Run("get_invalid_id", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/"+c.name+"/x", nil)
+				c.get(s)(rec, mux.SetURLVars(req, map[string]string{"id": "x"}))
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			})
+			t.Run("related", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				// the mock responds the sample of the resource whatever the path
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, c.relatedPath, nil)
+				parentID := path.Base(path.Dir(c.relatedPath))
+				c.related(s)(rec, mux.SetURLVars(req, map[string]string{"id": parentID}))
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, []int{c.id}, decodeResourceIDs(t, rec))
+			})
+			t.Run("related_invalid_id", func(t *testing.T) {
+				t.Parallel()
+				s := newServer()
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, c.relatedPath, nil)
+				c.related(s)(rec, mux.SetURLVars(req, map[string]string{"id": "x"}))
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			})
+		})
+	}
+}
+
+// decodeResourceIDs decodes the ids of the results of a resource list response
+func decodeResourceIDs(t *testing.T, rec *httptest.ResponseRecorder) []int {
+	var list struct {
+		Results []struct {
+			ID int `json:"id"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	ids := make([]int, 0, len(list.Results))
+	for _, result := range list.Results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+// decodeResourceID decodes the id of a resource response
+func decodeResourceID(t *testing.T, rec *httptest.ResponseRecorder) int {
+	var result struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+	return result.ID
+}
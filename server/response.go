@@ -0,0 +1,231 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache_Response_Max_Entries is the number of encoded responses kept, a response is kept per cache key
+const Cache_Response_Max_Entries = 1000
+
+// encodedResponse is a json response body encoded once for a version of a cached value
+type encodedResponse struct {
+	body []byte
+	// gzipped is the gzip encoded body, it is nil when it isn't smaller than the body
+	gzipped []byte
+	// etag is the strong etag of the body, the gzip encoded body has its own
+	etag string
+}
+
+// encodeResponse encodes v like writeJSON does, with its gzip variant if compress is set
+func encodeResponse(v interface{}, compress bool) (*encodedResponse, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body.Bytes())
+	resp := &encodedResponse{
+		body: body.Bytes(),
+		etag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+	if !compress {
+		return resp, nil
+	}
+	var gzipped bytes.Buffer
+	// the body is compressed once per version, it is worth the best compression
+	zw, err := gzip.NewWriterLevel(&gzipped, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(resp.body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if gzipped.Len() < body.Len() {
+		resp.gzipped = gzipped.Bytes()
+	}
+	return resp, nil
+}
+
+// gzipETag is the etag of the gzip encoded body
+func (resp *encodedResponse) gzipETag() string {
+	return strings.TrimSuffix(resp.etag, `"`) + `-gzip"`
+}
+
+// writeCachedJSON responds the cached value of cacheKey encoded in json, the value is fresh for ttl
+// the encoded response is kept for the version of the value, so that the next requests are served without encoding it
+// the version is the time the value was cached, a value cached without its time is encoded on every request
+func (s *Server) writeCachedJSON(w http.ResponseWriter,
+	r *http.Request,
+	cacheKey string,
+	cached cachedValue,
+	ttl time.Duration,
+) {
+	if cached.storedAt.IsZero() {
+		s.writeDerivedJSON(w, r, cached.value, cached, ttl)
+		return
+	}
+	version := strconv.FormatInt(cached.storedAt.UnixNano(), 10)
+	if v, ok := s.responses.Get(cacheKey, version); ok {
+		writeEncoded(w, r, v.(*encodedResponse), cached.storedAt, s.cacheControl(cached, ttl))
+		return
+	}
+	// concurrent requests of a new version encode it once
+	v, err, _ := s.requestGroup.Do("response_"+cacheKey+"_"+version, func() (interface{}, error) {
+		resp, err := encodeResponse(cached.value, true)
+		if err != nil {
+			return nil, err
+		}
+		s.responses.Set(cacheKey, version, resp)
+		return resp, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeEncoded(w, r, v.(*encodedResponse), cached.storedAt, s.cacheControl(cached, ttl))
+}
+
+// writeDerivedJSON responds v encoded in json, v is derived from the cached value e.g. a page of a cached list
+// it is validated like the cached value, but it isn't kept
+func (s *Server) writeDerivedJSON(w http.ResponseWriter,
+	r *http.Request,
+	v interface{},
+	cached cachedValue,
+	ttl time.Duration,
+) {
+	resp, err := encodeResponse(v, false)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeEncoded(w, r, resp, cached.storedAt, s.cacheControl(cached, ttl))
+}
+
+// cacheControl is the Cache-Control header of a cached value fresh for ttl
+// clients may use the value until it is stale for the server too
+func (s *Server) cacheControl(cached cachedValue, ttl time.Duration) string {
+	maxAge := ttl
+	if !cached.storedAt.IsZero() {
+		maxAge -= s.clock().Sub(cached.storedAt)
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	cacheControl := fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		int64(maxAge/time.Second), int64(Stale_While_Revalidate/time.Second))
+	if s.serveStaleOnError {
+		cacheControl += fmt.Sprintf(", stale-if-error=%d", int64(Stale_If_Error/time.Second))
+	}
+	return cacheControl
+}
+
+// invalidateResponses drops the encoded responses of the cache keys
+func (s *Server) invalidateResponses(cacheKeys ...string) {
+	for _, cacheKey := range cacheKeys {
+		s.responses.Delete(cacheKey)
+	}
+}
+
+// writeEncoded responds an encoded response, gzip encoded if the client accepts it
+// it responds 304 without body when the client already has the response
+// lastModified is the time the response was cached, it is zero when unknown
+func writeEncoded(w http.ResponseWriter,
+	r *http.Request,
+	resp *encodedResponse,
+	lastModified time.Time,
+	cacheControl string,
+) {
+	h := w.Header()
+	body, etag, gzipped := resp.body, resp.etag, false
+	if resp.gzipped != nil {
+		h.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			body, etag, gzipped = resp.gzipped, resp.gzipETag(), true
+		}
+	}
+	h.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if cacheControl != "" {
+		h.Set("Cache-Control", cacheControl)
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", "application/json")
+	if gzipped {
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		// log error
+		log.Println("write error", err)
+	}
+}
+
+// notModified reports whether the conditional headers of r match the response of etag and lastModified
+// If-Modified-Since is ignored when If-None-Match is sent
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match uses the weak comparison
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// Last-Modified has a second precision
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r accepts gzip, explicitly or with *
+func acceptsGzip(r *http.Request) bool {
+	wildcard := false
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(coding, ";")
+		name := strings.TrimSpace(params[0])
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		accepted := true
+		for _, param := range params[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				weight, err := strconv.ParseFloat(q[2:], 64)
+				accepted = err == nil && weight > 0
+			}
+		}
+		if name == "gzip" {
+			return accepted
+		}
+		wildcard = accepted
+	}
+	return wildcard
+}
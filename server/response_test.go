@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeResponse(t *testing.T) {
+	t.Parallel()
+	list := make([]int, 1000)
+	for i := range list {
+		list[i] = 1011000 + i
+	}
+	resp, err := encodeResponse(list, true)
+	require.NoError(t, err)
+	// the same body as writeJSON
+	rec := httptest.NewRecorder()
+	writeJSON(rec, list)
+	require.Equal(t, rec.Body.Bytes(), resp.body)
+	require.Regexp(t, `^"[0-9a-f]{32}"$`, resp.etag)
+	require.Equal(t, resp.etag[:33]+`-gzip"`, resp.gzipETag())
+
+	require.Less(t, len(resp.gzipped), len(resp.body))
+	zr, err := gzip.NewReader(bytes.NewReader(resp.gzipped))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, resp.body, body)
+
+	// a small body isn't compressed
+	resp, err = encodeResponse([]int{1}, true)
+	require.NoError(t, err)
+	require.Nil(t, resp.gzipped)
+	resp, err = encodeResponse(list, false)
+	require.NoError(t, err)
+	require.Nil(t, resp.gzipped)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		header   string
+		accepted bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"br", false},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"gzip;q=0, *", false},
+		{"*;q=0", false},
+		{"identity, *;q=0.1", true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/characters", nil)
+		r.Header.Set("Accept-Encoding", c.header)
+		require.Equal(t, c.accepted, acceptsGzip(r), c.header)
+	}
+}
+
+func TestWriteCachedJSON(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{
+		marvelAPI: marvel.NewAPI("", "", ""),
+		cacher:    cacher.NewCacher(),
+		values:    cacher.NewValueCache(10),
+		responses: cacher.NewValueCache(10),
+		now:       func() time.Time { return now },
+	}
+	list := make([]int, 1000)
+	for i := range list {
+		list[i] = 1011000 + i
+	}
+	s.setCached(Characters_Cache_Key, &list, time.Hour)
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/characters", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	rec := get("")
+	version := strconv.FormatInt(now.UnixNano(), 10)
+	v, ok := s.responses.Get(Characters_Cache_Key, version)
+	require.True(t, ok)
+	resp := v.(*encodedResponse)
+	require.Equal(t, resp.body, rec.Body.Bytes())
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, resp.etag, rec.Header().Get("ETag"))
+	require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+
+	rec = get("gzip")
+	require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	require.Equal(t, resp.gzipETag(), rec.Header().Get("ETag"))
+	require.Equal(t, resp.gzipped, rec.Body.Bytes())
+	// the response of the version is encoded once
+	v, ok = s.responses.Get(Characters_Cache_Key, version)
+	require.True(t, ok)
+	require.Same(t, resp, v)
+
+	// the list got updated by the sync
+	s.storeCharacterRecords([]*marvel.MarvelCharacter{{ID: 1}})
+	_, ok = s.responses.Get(Characters_Cache_Key, version)
+	require.False(t, ok)
+	now = now.Add(time.Minute)
+	s.setCached(Characters_Cache_Key, &[]int{1}, time.Hour)
+	rec = get("gzip")
+	require.Equal(t, "[1]\n", rec.Body.String())
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+
+	t.Run("legacy", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{
+			marvelAPI: marvel.NewAPI("", "", ""),
+			cacher:    cacher.NewCacher(),
+			responses: cacher.NewValueCache(10),
+		}
+		// a value cached without its time has no version, it is encoded on every request
+		s.cacher.Set(Characters_Cache_Key, "[0,1,2]")
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
+		require.Equal(t, "[0,1,2]\n", rec.Body.String())
+		require.NotEmpty(t, rec.Header().Get("ETag"))
+		require.Empty(t, rec.Header().Get("Last-Modified"))
+	})
+}
+
+func TestConditionalGet(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{
+		marvelAPI: marvel.NewAPI("", "", ""),
+		cacher:    cacher.NewCacher(),
+		values:    cacher.NewValueCache(10),
+		responses: cacher.NewValueCache(10),
+		now:       func() time.Time { return now },
+	}
+	s.setCached(Characters_Cache_Key, &[]int{1, 2, 3}, Character_List_Cache_TTL)
+	s.setCached(buildCharacterInfoCacheKey(1), &marvel.MarvelCharacter{ID: 1, Name: "one"}, Detail_Cache_TTL)
+	now = now.Add(time.Minute)
+	lastModified := "Fri, 01 Jan 2021 00:00:00 GMT"
+
+	rec := getCharacterList(s, "/characters", http.Header{})
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, lastModified, rec.Header().Get("Last-Modified"))
+	require.Equal(t, "public, max-age=172740, stale-while-revalidate=3600", rec.Header().Get("Cache-Control"))
+
+	cases := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{"etag", http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"weak_etag", http.Header{"If-None-Match": {`"other", W/` + etag}}, http.StatusNotModified},
+		{"any_etag", http.Header{"If-None-Match": {"*"}}, http.StatusNotModified},
+		{"changed_etag", http.Header{"If-None-Match": {`"other"`}}, http.StatusOK},
+		{"not_modified_since", http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"modified_since", http.Header{"If-Modified-Since": {"Thu, 31 Dec 2020 23:59:59 GMT"}}, http.StatusOK},
+		{"invalid_since", http.Header{"If-Modified-Since": {"yesterday"}}, http.StatusOK},
+		// If-Modified-Since is ignored with If-None-Match
+		{"etag_precedence", http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}, http.StatusOK},
+	}
+	for _, c := range cases {
+		rec := getCharacterList(s, "/characters", c.header)
+		require.Equal(t, c.status, rec.Code, c.name)
+		require.Equal(t, etag, rec.Header().Get("ETag"), c.name)
+		if c.status == http.StatusNotModified {
+			require.Empty(t, rec.Body.String(), c.name)
+			require.Equal(t, lastModified, rec.Header().Get("Last-Modified"), c.name)
+			require.NotEmpty(t, rec.Header().Get("Cache-Control"), c.name)
+		}
+	}
+
+	t.Run("page", func(t *testing.T) {
+		t.Parallel()
+		rec := getCharacterList(s, "/characters?limit=2", http.Header{})
+		require.Equal(t, http.StatusOK, rec.Code)
+		pageETag := rec.Header().Get("ETag")
+		require.NotEqual(t, etag, pageETag)
+		require.Equal(t, lastModified, rec.Header().Get("Last-Modified"))
+		rec = getCharacterList(s, "/characters?limit=2", http.Header{"If-None-Match": {pageETag}})
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		// the links are still sent
+		require.NotEmpty(t, rec.Header().Get("Link"))
+	})
+	t.Run("info", func(t *testing.T) {
+		t.Parallel()
+		get := func(header http.Header) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodGet, "/characters/1", nil)
+			r.Header = header
+			rec := httptest.NewRecorder()
+			s.GetCharacterInfo(rec, mux.SetURLVars(r, map[string]string{"id": "1"}))
+			return rec
+		}
+		rec := get(http.Header{})
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "public, max-age=21540, stale-while-revalidate=3600", rec.Header().Get("Cache-Control"))
+		rec = get(http.Header{"If-None-Match": {rec.Header().Get("ETag")}})
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		require.Empty(t, rec.Body.String())
+	})
+	t.Run("gzip", func(t *testing.T) {
+		t.Parallel()
+		list := make([]int, 1000)
+		s := &Server{
+			marvelAPI:         marvel.NewAPI("", "", ""),
+			cacher:            cacher.NewCacher(),
+			responses:         cacher.NewValueCache(10),
+			serveStaleOnError: true,
+		}
+		s.setCached(Characters_Cache_Key, &list, Character_List_Cache_TTL)
+		rec := getCharacterList(s, "/characters", http.Header{"Accept-Encoding": {"gzip"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		etag := rec.Header().Get("ETag")
+		require.Contains(t, etag, "-gzip")
+		require.Contains(t, rec.Header().Get("Cache-Control"), "stale-if-error=86400")
+		rec = getCharacterList(s, "/characters", http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {etag}})
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		// the client doesn't accept the representation it has anymore
+		rec = getCharacterList(s, "/characters", http.Header{"If-None-Match": {etag}})
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func getCharacterList(s *Server, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header = header
+	rec := httptest.NewRecorder()
+	s.GetListCharacters(rec, r)
+	return rec
+}
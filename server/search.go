@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Character_Search_Cache_Key = "character_search"
+
+	// Search_Default_Limit is the page size of the search when limit is not set, same as marvel
+	Search_Default_Limit = 20
+)
+
+// CharacterSearchResult is a page of characters matching a search
+type CharacterSearchResult struct {
+	Total   int                       `json:"total"`
+	Offset  int                       `json:"offset"`
+	Limit   int                       `json:"limit"`
+	Results []*marvel.MarvelCharacter `json:"results"`
+}
+
+// SearchCharacters responds the characters matching the name or nameStartsWith query parameters
+// it is answered from the local name index when the full character list is loaded, from marvel otherwise
+func (s *Server) SearchCharacters(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts, err := parseListOptions(query)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := marvel.CharacterFilter{
+		ListOptions:    opts,
+		Name:           query.Get("name"),
+		NameStartsWith: query.Get("nameStartsWith"),
+	}
+	if filter.Name == "" && filter.NameStartsWith == "" {
+		writeProblem(w, http.StatusBadRequest, "name or nameStartsWith is required")
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = Search_Default_Limit
+	}
+	// the index only knows the names, ordering and date filters need marvel
+	if s.index.Ready() && filter.OrderBy == "" && filter.ModifiedSince.IsZero() {
+		results, total := s.index.Search(filter.Name, filter.NameStartsWith, filter.Offset, filter.Limit)
+		w.Header().Set("X-Search-Source", "index")
+		writeJSON(w, &CharacterSearchResult{
+			Total:   total,
+			Offset:  filter.Offset,
+			Limit:   filter.Limit,
+			Results: results,
+		})
+		return
+	}
+	cacheKey := Character_Search_Cache_Key + "_" + filter.Values().Encode()
+	v, err := s.getCached(r.Context(), cacheKey, List_Cache_TTL, func() interface{} {
+		return new(CharacterSearchResult)
+	}, func(ctx context.Context) (interface{}, error) {
+		list, err := s.marvelAPI.ListCharactersWithContext(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result := &CharacterSearchResult{
+			Total:   list.Total,
+			Offset:  list.Offset,
+			Limit:   list.Limit,
+			Results: make([]*marvel.MarvelCharacter, 0, len(list.Results)),
+		}
+		for _, character := range list.Results {
+			result.Results = append(result.Results, character.Slim())
+		}
+		return result, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("X-Search-Source", "marvel")
+	writeJSON(w, v.(*CharacterSearchResult))
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/hauxe/xendit_pratice/marvel"
+	"github.com/hauxe/xendit_pratice/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchCharacters(t *testing.T) {
+	t.Parallel()
+	t.Run("missing_name", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{
+			marvelAPI: marvel.NewAPI("", "", ""),
+			cacher:    cacher.NewCacher(),
+		}
+		rec := httptest.NewRecorder()
+		s.SearchCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters/search", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("from_marvel", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
+		require.NoError(t, err)
+		s := &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+			cacher:    cacher.NewCacher(),
+		}
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			s.SearchCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters/search?nameStartsWith=3-D", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, "marvel", rec.Header().Get("X-Search-Source"))
+			var result CharacterSearchResult
+			err = json.NewDecoder(rec.Body).Decode(&result)
+			require.NoError(t, err)
+			require.Equal(t, 1, result.Total)
+			require.Equal(t, &marvel.MarvelCharacter{ID: 1011334, Name: "3-D Man", Description: "test description"}, result.Results[0])
+		}
+		require.Equal(t, uint64(1), s.marvelAPI.Stats().Requests)
+	})
+	t.Run("from_index", func(t *testing.T) {
+		t.Parallel()
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+		require.NoError(t, err)
+		s := &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+			cacher:    cacher.NewCacher(),
+		}
+		// warm the full list
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		requests := s.marvelAPI.Stats().Requests
+
+		rec = httptest.NewRecorder()
+		s.SearchCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters/search?nameStartsWith=2-d&limit=10", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "index", rec.Header().Get("X-Search-Source"))
+		var result CharacterSearchResult
+		err = json.NewDecoder(rec.Body).Decode(&result)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		require.Equal(t, 10, result.Limit)
+		require.Equal(t, 1011335, result.Results[0].ID)
+		require.Equal(t, requests, s.marvelAPI.Stats().Requests)
+	})
+}
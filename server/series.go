@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Series_List_Cache_Key      = "series_list"
+	Series_Cache_Key           = "series_detail"
+	Character_Series_Cache_Key = "character_series"
+)
+
+// series is the series resource of marvel api
+func (s *Server) series() marvelResource {
+	return marvelResource{
+		name:              "series",
+		listCacheKey:      Series_List_Cache_Key,
+		detailCacheKey:    Series_Cache_Key,
+		characterCacheKey: Character_Series_Cache_Key,
+		newList: func() interface{} {
+			return new(marvel.SeriesList)
+		},
+		newDetail: func() interface{} {
+			return new(marvel.Series)
+		},
+		parseFilter: func(query url.Values) (resourceFilter, error) {
+			return parseSeriesFilter(query)
+		},
+		list: func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListSeriesWithContext(ctx, filter.(marvel.SeriesFilter))
+		},
+		get: func(ctx context.Context, id int) (interface{}, error) {
+			return s.marvelAPI.GetSeriesWithContext(ctx, id)
+		},
+		listByCharacter: func(ctx context.Context, charID int, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCharacterSeriesWithContext(ctx, charID, filter.(marvel.SeriesFilter))
+		},
+	}
+}
+
+// ListSeries responds a page of series matching the marvel filters in query parameters
+func (s *Server) ListSeries(w http.ResponseWriter, r *http.Request) {
+	s.listResource(w, r, s.series())
+}
+
+// GetSeries responds the series by id
+func (s *Server) GetSeries(w http.ResponseWriter, r *http.Request) {
+	s.getResource(w, r, s.series())
+}
+
+// ListCharacterSeries responds a page of series of the character
+func (s *Server) ListCharacterSeries(w http.ResponseWriter, r *http.Request) {
+	s.listCharacterResource(w, r, s.series())
+}
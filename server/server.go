@@ -1,12 +1,14 @@
 package server
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -17,13 +19,51 @@ import (
 )
 
 const (
-	Characters_Cache_Key     = "characters"
-	Character_Info_Cache_Key = "character_info"
+	Characters_Cache_Key         = "characters"
+	Characters_Summary_Cache_Key = "characters_summary"
+	Character_Info_Cache_Key     = "character_info"
+	Character_Detail_Cache_Key   = "character_detail"
 
 	API_PUBLIC_KEY  = "API_PUBLIC_KEY"
 	API_PRIVATE_KEY = "API_PRIVATE_KEY"
+	// ADMIN_TOKEN is the bearer token of the admin routes, they are disabled when it is not set
+	ADMIN_TOKEN = "ADMIN_TOKEN"
+	// CACHE_BACKEND is memory (default), redis or two tiers separated by a comma e.g. memory,redis
+	CACHE_BACKEND  = "CACHE_BACKEND"
+	REDIS_ADDR     = "REDIS_ADDR"
+	REDIS_PASSWORD = "REDIS_PASSWORD"
+	// REDIS_KEY_PREFIX namespaces the keys of the service in a shared redis database, empty doesn't namespace them
+	REDIS_KEY_PREFIX = "REDIS_KEY_PREFIX"
+	// CACHE_SNAPSHOT_PATH is the file the memory cache is saved to, no snapshot is taken when it is not set
+	CACHE_SNAPSHOT_PATH = "CACHE_SNAPSHOT_PATH"
+
+	Cache_Backend_Memory = "memory"
+	Cache_Backend_Redis  = "redis"
+	Redis_Default_Addr   = "localhost:6379"
+	Redis_Default_Prefix = "marvel:"
 
 	UpdateCharacterJobMinute = 24 * 60 // 1 day
+
+	// the full character list outlives the update job tick, so the job keeps it fresh
+	Character_List_Cache_TTL = 2 * UpdateCharacterJobMinute * time.Minute
+	// filtered lists change when marvel publishes, they are refreshed often
+	List_Cache_TTL = time.Hour
+	// single resources like character descriptions rarely change
+	Detail_Cache_TTL = 6 * time.Hour
+	// Cache_Cleanup_Interval is how often the expired keys are removed from memory
+	Cache_Cleanup_Interval  = 10 * time.Minute
+	Cache_Snapshot_Interval = 5 * time.Minute
+	// Shutdown_Timeout is how long the in-flight requests are waited for on shutdown
+	Shutdown_Timeout = 10 * time.Second
+	// the cache keys depend on client input, bound the memory they can take
+	Cache_Max_Entries = 50000
+	Cache_Max_Bytes   = 128 << 20 // 128MB
+
+	// marvel allows 3000 calls per day for a key
+	MarvelDailyQuota   = 3000
+	MarvelQuotaReserve = 300
+	MarvelRateLimit    = 10 // requests per second
+	MarvelRateBurst    = 10
 )
 
 type Server struct {
@@ -32,6 +72,22 @@ type Server struct {
 	marvelAPI    *marvel.API
 	cacher       cacher.Cacher
 	shutdown     chan struct{}
+	// index is built from the full character list for name search
+	index        characterIndex
+	adminToken   string
+	snapshotPath string
+	// serveStaleOnError serves the expired values kept for Stale_If_Error when marvel fails
+	serveStaleOnError bool
+	// notFoundTTL is how long an unknown character id is cached, 0 doesn't cache it
+	notFoundTTL time.Duration
+	// codec encodes the cached values, json when it is nil
+	codec cacher.Codec
+	// values keeps the decoded cached values, nothing is kept when it is nil
+	values *cacher.ValueCache
+	// responses keeps the encoded responses of the hot endpoints, nothing is kept when it is nil
+	responses *cacher.ValueCache
+	// now is time.Now, tests replace it to age the cached values
+	now func() time.Time
 }
 
 // NewServer create server
@@ -45,118 +101,285 @@ func NewServer() (*Server, error) {
 	if !found {
 		return nil, fmt.Errorf("couldn't find environment variable for key %s", API_PRIVATE_KEY)
 	}
-	return &Server{
-		router:    mux.NewRouter(),
-		marvelAPI: marvel.NewAPI("", apiPublicKey, apiPrivateKey),
-		cacher:    cacher.NewCacher(),
-		shutdown:  make(chan struct{}),
-	}, nil
+	serveStaleOnError := false
+	if v, found := os.LookupEnv(SERVE_STALE_ON_ERROR); found {
+		var err error
+		serveStaleOnError, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s for environment variable %s", v, SERVE_STALE_ON_ERROR)
+		}
+	}
+	notFoundTTL := Not_Found_Cache_TTL
+	if v, found := os.LookupEnv(NOT_FOUND_CACHE_TTL); found {
+		var err error
+		notFoundTTL, err = time.ParseDuration(v)
+		if err != nil || notFoundTTL < 0 {
+			return nil, fmt.Errorf("invalid value %s for environment variable %s", v, NOT_FOUND_CACHE_TTL)
+		}
+	}
+	codec, err := newCodec()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCacher()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		router: mux.NewRouter(),
+		marvelAPI: marvel.NewAPI("", apiPublicKey, apiPrivateKey,
+			marvel.WithRateLimit(MarvelRateLimit, MarvelRateBurst),
+			marvel.WithDailyQuota(MarvelDailyQuota, MarvelQuotaReserve),
+		),
+		cacher:       c,
+		shutdown:     make(chan struct{}),
+		adminToken:   os.Getenv(ADMIN_TOKEN),
+		snapshotPath: os.Getenv(CACHE_SNAPSHOT_PATH),
+
+		serveStaleOnError: serveStaleOnError,
+		notFoundTTL:       notFoundTTL,
+		codec:             codec,
+		values:            cacher.NewValueCache(Cache_Decoded_Max_Entries),
+		responses:         cacher.NewValueCache(Cache_Response_Max_Entries),
+	}
+	s.loadSnapshot()
+	return s, nil
 }
 
 // Start server and listenning on 8080 port
-// this function is a blocking function
+// this function is a blocking function, it returns after a graceful shutdown on SIGINT or SIGTERM
 func (s *Server) Start() {
-	defer close(s.shutdown)
+	defer closeCacher(s.cacher)
 	// start async job update character info
 	// when the server shutting down, it will cause all async job shutdown too
 	jobs.StartUpdateCharacterListJob(s.shutdown,
 		time.Minute*time.Duration(UpdateCharacterJobMinute),
 		s.cacher,
 		Characters_Cache_Key,
+		Character_List_Cache_TTL,
 		s.marvelAPI,
+		s.storeCharacterRecords,
 	)
+	var snapshotDone <-chan struct{}
+	if s.snapshotPath != "" {
+		snapshotDone = jobs.StartSnapshotJob(s.shutdown, Cache_Snapshot_Interval, s.cacher, s.snapshotPath)
+	}
 
 	// build routes
 	s.router.Path("/characters").HandlerFunc(s.GetListCharacters)
+	s.router.Path("/characters/search").HandlerFunc(s.SearchCharacters)
+	s.router.Path("/characters/summary").HandlerFunc(s.GetCharacterSummaries)
 	s.router.Path("/characters/{id:[0-9]+}").HandlerFunc(s.GetCharacterInfo)
+	s.router.Path("/characters/{id:[0-9]+}/detail").HandlerFunc(s.GetCharacterDetail)
+	s.router.Path("/characters/{id:[0-9]+}/comics").HandlerFunc(s.ListCharacterComics)
+	s.router.Path("/comics").HandlerFunc(s.ListComics)
+	s.router.Path("/comics/{id:[0-9]+}").HandlerFunc(s.GetComic)
+	s.router.Path("/characters/{id:[0-9]+}/series").HandlerFunc(s.ListCharacterSeries)
+	s.router.Path("/series").HandlerFunc(s.ListSeries)
+	s.router.Path("/series/{id:[0-9]+}").HandlerFunc(s.GetSeries)
+	s.router.Path("/characters/{id:[0-9]+}/events").HandlerFunc(s.ListCharacterEvents)
+	s.router.Path("/events").HandlerFunc(s.ListEvents)
+	s.router.Path("/events/{id:[0-9]+}").HandlerFunc(s.GetEvent)
+	s.router.Path("/characters/{id:[0-9]+}/stories").HandlerFunc(s.ListCharacterStories)
+	s.router.Path("/stories").HandlerFunc(s.ListStories)
+	s.router.Path("/stories/{id:[0-9]+}").HandlerFunc(s.GetStory)
+	s.router.Path("/creators").HandlerFunc(s.ListCreators)
+	s.router.Path("/creators/{id:[0-9]+}").HandlerFunc(s.GetCreator)
+	s.router.Path("/creators/{id:[0-9]+}/comics").HandlerFunc(s.ListCreatorComics)
+	s.router.Path("/creators/{id:[0-9]+}/characters").HandlerFunc(s.ListCreatorCharacters)
+	s.router.Path("/admin/cache").Methods(http.MethodGet).HandlerFunc(s.adminOnly(s.GetCacheStats))
+	s.router.Path("/admin/cache").Methods(http.MethodDelete).HandlerFunc(s.adminOnly(s.DeleteCache))
+	s.router.Path("/admin/cache/{key}").Methods(http.MethodGet).HandlerFunc(s.adminOnly(s.GetCacheKey))
+	s.router.Path("/admin/cache/{key}").Methods(http.MethodDelete).HandlerFunc(s.adminOnly(s.DeleteCacheKey))
+	srv := &http.Server{Addr: ":8080", Handler: s.router}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), Shutdown_Timeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			// log error
+			log.Println("shutdown error", err)
+		}
+	}()
 	fmt.Println("Start Listenning at :8080")
-	if err := http.ListenAndServe(":8080", s.router); err != nil {
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
+	<-stopped
+	// stop the async jobs before taking the last snapshot
+	close(s.shutdown)
+	if snapshotDone != nil {
+		// a periodic snapshot in progress must not replace the last one
+		<-snapshotDone
+	}
+	s.saveSnapshot()
 }
 
+// GetListCharacters responds the ids of all characters
+// with limit, offset or cursor query parameters, it responds a page envelope and Link headers instead
+// with expand=true, it responds the id, name and description of the characters like GetCharacterSummaries
 func (s *Server) GetListCharacters(w http.ResponseWriter, r *http.Request) {
-	v, err, _ := s.requestGroup.Do(Characters_Cache_Key, func() (interface{}, error) {
-		// get from cache first
-		var list []int
-		value, ok := s.cacher.Get(Characters_Cache_Key)
-		if ok {
-			err := json.Unmarshal([]byte(value), &list)
-			if err == nil {
-				return list, nil
-			}
-		}
-		list, err := s.marvelAPI.GetAllCharacters()
+	expand, err := queryBool(r.URL.Query(), "expand")
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if expand {
+		s.GetCharacterSummaries(w, r)
+		return
+	}
+	params, paginated, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cached, err := s.getCachedValue(r.Context(), Characters_Cache_Key, Character_List_Cache_TTL, 0, func() interface{} {
+		return new([]int)
+	}, func(ctx context.Context) (interface{}, error) {
+		records, err := s.getCharacterRecords(ctx)
 		if err != nil {
 			return nil, err
 		}
-		v, err := json.Marshal(list)
-		if err == nil {
-			s.cacher.Set(Characters_Cache_Key, string(v))
+		characters := *records.value.(*[]*marvel.MarvelCharacter)
+		list := make([]int, 0, len(characters))
+		for _, character := range characters {
+			list = append(list, character.ID)
 		}
-		return list, nil
+		// the list is as old as the records, stale records are refreshed in background
+		return cachedValue{value: &list, storedAt: records.storedAt}, nil
 	})
 	if err != nil {
-		http.Error(w, "internal server error", 500)
+		writeError(w, err)
 		return
 	}
-	list := v.([]int)
-	w.WriteHeader(200)
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	err = encoder.Encode(&list)
-	// log error
-	if err != nil {
-		log.Println("encode error", err)
+	if paginated {
+		page := paginate(w, r, *cached.value.(*[]int), params)
+		s.writeDerivedJSON(w, r, page, cached, Character_List_Cache_TTL)
+		return
 	}
+	s.writeCachedJSON(w, r, Characters_Cache_Key, cached, Character_List_Cache_TTL)
 }
 
-func (s *Server) GetCharacterInfo(w http.ResponseWriter, r *http.Request) {
-	id := mux.Vars(r)["id"]
-	charID, err := strconv.Atoi(id)
+// GetCharacterSummaries responds the id, name and description of all characters
+// it supports the same pagination as GetListCharacters
+func (s *Server) GetCharacterSummaries(w http.ResponseWriter, r *http.Request) {
+	params, paginated, err := parsePageParams(r.URL.Query())
 	if err != nil {
-		w.WriteHeader(400)
-		_, _ = w.Write([]byte("invalid character id"))
+		writeProblem(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	cacheKey := buildCharacterInfoCacheKey(charID)
-	v, err, _ := s.requestGroup.Do(cacheKey, func() (interface{}, error) {
-		// get from cache first
-		info := new(marvel.MarvelCharacter)
-		value, ok := s.cacher.Get(cacheKey)
-		if ok {
-			err := json.Unmarshal([]byte(value), info)
-			if err == nil {
-				return info, nil
-			}
-			// log error
-			log.Println("decode error", err)
-		}
-		info, err := s.marvelAPI.GetCharacterInfo(charID)
+	cached, err := s.getCharacterRecords(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if paginated {
+		page := paginateSummaries(w, r, *cached.value.(*[]*marvel.MarvelCharacter), params)
+		s.writeDerivedJSON(w, r, page, cached, Character_List_Cache_TTL)
+		return
+	}
+	s.writeCachedJSON(w, r, Characters_Summary_Cache_Key, cached, Character_List_Cache_TTL)
+}
+
+// getCharacterRecords gets the slim records of all characters from cache
+// on cache miss it syncs the full character list from marvel and stores the records
+// the records are a *[]*marvel.MarvelCharacter
+func (s *Server) getCharacterRecords(ctx context.Context) (cachedValue, error) {
+	cached, err := s.getCachedValue(ctx, Characters_Summary_Cache_Key, Character_List_Cache_TTL, 0, func() interface{} {
+		return new([]*marvel.MarvelCharacter)
+	}, func(ctx context.Context) (interface{}, error) {
+		characters, err := s.marvelAPI.GetAllCharacterRecordsWithContext(ctx)
 		if err != nil {
 			return nil, err
 		}
-		v, err := json.Marshal(info)
-		if err == nil {
-			s.cacher.Set(cacheKey, string(v))
-		}
-		return info, nil
+		s.cacheCharacterInfos(characters)
+		s.index.Build(characters)
+		return &characters, nil
+	})
+	if err != nil {
+		return cachedValue{}, err
+	}
+	if !s.index.Ready() {
+		// the records may be cached by a previous sync, build the index from them
+		s.index.Build(*cached.value.(*[]*marvel.MarvelCharacter))
+	}
+	return cached, nil
+}
+
+// storeCharacterRecords keeps the records of a full character list sync
+// it caches the summary list and the info of every character, then rebuilds the name index
+// the encoded responses of the lists are dropped, the next requests encode the new lists
+func (s *Server) storeCharacterRecords(characters []*marvel.MarvelCharacter) {
+	s.invalidateResponses(Characters_Cache_Key, Characters_Summary_Cache_Key)
+	// the same type as the records decoded by getCharacterRecords
+	s.setCached(Characters_Summary_Cache_Key, &characters, Character_List_Cache_TTL)
+	s.cacheCharacterInfos(characters)
+	s.index.Build(characters)
+}
+
+// cacheCharacterInfos caches the info of every character so that GetCharacterInfo is served from cache
+func (s *Server) cacheCharacterInfos(characters []*marvel.MarvelCharacter) {
+	for _, character := range characters {
+		s.setCached(buildCharacterInfoCacheKey(character.ID), character, Detail_Cache_TTL)
+	}
+}
+
+func (s *Server) GetCharacterInfo(w http.ResponseWriter, r *http.Request) {
+	charID, ok := parseID(w, r, "invalid character id")
+	if !ok {
+		return
+	}
+	// unknown ids are cached too, so that they don't hit marvel on every request
+	cacheKey := buildCharacterInfoCacheKey(charID)
+	cached, err := s.getCachedValue(r.Context(), cacheKey, Detail_Cache_TTL, s.notFoundTTL, func() interface{} {
+		return new(marvel.MarvelCharacter)
+	}, func(ctx context.Context) (interface{}, error) {
+		return s.marvelAPI.GetCharacterInfoWithContext(ctx, charID)
 	})
 	if err != nil {
-		http.Error(w, "internal server error", 500)
+		writeError(w, err)
+		return
+	}
+	s.writeCachedJSON(w, r, cacheKey, cached, Detail_Cache_TTL)
+}
+
+// GetCharacterDetail responds the full character record including thumbnail,
+// comics, series, stories, events and urls
+func (s *Server) GetCharacterDetail(w http.ResponseWriter, r *http.Request) {
+	charID, ok := parseID(w, r, "invalid character id")
+	if !ok {
 		return
 	}
-	info := v.(*marvel.MarvelCharacter)
-	w.WriteHeader(200)
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	err = encoder.Encode(info)
-	// log error
+	v, err := s.getCached(r.Context(), buildCharacterDetailCacheKey(charID), Detail_Cache_TTL, func() interface{} {
+		return new(marvel.Character)
+	}, func(ctx context.Context) (interface{}, error) {
+		return s.marvelAPI.GetCharacterWithContext(ctx, charID)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, v.(*marvel.Character))
+}
+
+// parseID parses the id route variable, it responds 400 with detail if the id is invalid
+func parseID(w http.ResponseWriter, r *http.Request, detail string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		log.Println("encode error", err)
+		writeProblem(w, http.StatusBadRequest, detail)
+		return 0, false
 	}
+	return id, true
 }
 
 func buildCharacterInfoCacheKey(id int) string {
 	return Character_Info_Cache_Key + "_" + strconv.Itoa(id)
 }
+
+func buildCharacterDetailCacheKey(id int) string {
+	return Character_Detail_Cache_Key + "_" + strconv.Itoa(id)
+}
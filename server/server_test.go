@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hauxe/xendit_pratice/cacher"
@@ -25,7 +26,7 @@ func TestGetListCharacters(t *testing.T) {
 		}
 		s.cacher.Set(Characters_Cache_Key, "[0,1,2]")
 		rec := httptest.NewRecorder()
-		s.GetListCharacters(rec, nil)
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
 		require.NotNil(t, rec.Body)
 		dec := json.NewDecoder(rec.Body)
 		var list []int
@@ -37,13 +38,13 @@ func TestGetListCharacters(t *testing.T) {
 		t.Parallel()
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
 		s := &Server{
 			marvelAPI: api,
 			cacher:    cacher.NewCacher(),
 		}
 		rec := httptest.NewRecorder()
-		s.GetListCharacters(rec, nil)
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
 		require.NotNil(t, rec.Body)
 		dec := json.NewDecoder(rec.Body)
 		var list []int
@@ -55,7 +56,7 @@ func TestGetListCharacters(t *testing.T) {
 		t.Parallel()
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
 		s := &Server{
 			marvelAPI: api,
 			cacher:    cacher.NewCacher(),
@@ -67,7 +68,7 @@ func TestGetListCharacters(t *testing.T) {
 			go func() {
 				defer wg.Done()
 				rec := httptest.NewRecorder()
-				s.GetListCharacters(rec, nil)
+				s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
 				require.NotNil(t, rec.Body)
 				dec := json.NewDecoder(rec.Body)
 				var list []int
@@ -80,6 +81,82 @@ func TestGetListCharacters(t *testing.T) {
 	})
 }
 
+func TestGetCharacterSummaries(t *testing.T) {
+	t.Parallel()
+	newServer := func(t *testing.T) *Server {
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+		require.NoError(t, err)
+		return &Server{
+			marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+			cacher:    cacher.NewCacher(),
+		}
+	}
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t)
+		rec := httptest.NewRecorder()
+		s.GetCharacterSummaries(rec, httptest.NewRequest(http.MethodGet, "/characters/summary", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list []*marvel.MarvelCharacter
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+		require.Len(t, list, 3)
+		require.Equal(t, &marvel.MarvelCharacter{ID: 1011334, Name: "1-D Man", Description: "test description"}, list[0])
+
+		// the sync populates the info of every character and the name index
+		v, ok := s.cacher.Get(buildCharacterInfoCacheKey(1011334))
+		require.True(t, ok)
+		entry, ok := cacher.DecodeEntry(v)
+		require.True(t, ok)
+		require.JSONEq(t, `{"id":1011334,"name":"1-D Man","description":"test description"}`, string(entry.Value))
+		require.True(t, s.index.Ready())
+		requests := s.marvelAPI.Stats().Requests
+
+		// the id list is derived from the cached records
+		rec = httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters", nil))
+		var ids []int
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&ids))
+		require.Equal(t, []int{1011334, 1011335, 1011336}, ids)
+		require.Equal(t, requests, s.marvelAPI.Stats().Requests)
+	})
+	t.Run("expand", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t)
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters?expand=true&limit=2", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page CharacterSummaryPage
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+		require.Equal(t, 3, page.Total)
+		require.Len(t, page.Results, 2)
+		require.NotEmpty(t, page.NextCursor)
+		require.Contains(t, rec.Header().Get("Link"), "expand=true")
+	})
+	t.Run("invalid_expand", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t)
+		rec := httptest.NewRecorder()
+		s.GetListCharacters(rec, httptest.NewRequest(http.MethodGet, "/characters?expand=abc", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("cached", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{
+			marvelAPI: marvel.NewAPI("", "", ""),
+			cacher:    cacher.NewCacher(),
+		}
+		s.cacher.Set(Characters_Summary_Cache_Key, `[{"id":1,"name":"test","description":"desc"}]`)
+		rec := httptest.NewRecorder()
+		s.GetCharacterSummaries(rec, httptest.NewRequest(http.MethodGet, "/characters/summary", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `[{"id":1,"name":"test","description":"desc"}]`, rec.Body.String())
+		// the index is built from the cached records
+		results, total := s.index.Search("", "te", 0, 10)
+		require.Equal(t, 1, total)
+		require.Equal(t, 1, results[0].ID)
+	})
+}
+
 func TestGetCharacterInfo(t *testing.T) {
 	t.Parallel()
 	t.Run("cached", func(t *testing.T) {
@@ -121,7 +198,7 @@ func TestGetCharacterInfo(t *testing.T) {
 		id := 1011334
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
 		s := &Server{
 			marvelAPI: api,
 			cacher:    cacher.NewCacher(),
@@ -143,12 +220,93 @@ func TestGetCharacterInfo(t *testing.T) {
 		require.Equal(t, "1-D Man", result.Name)
 		require.Equal(t, "test description", result.Description)
 	})
+	t.Run("not_found", func(t *testing.T) {
+		t.Parallel()
+		id := 1
+		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code":404,"status":"We couldn't find that character"}`))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		s := &Server{
+			marvelAPI: api,
+			cacher:    cacher.NewCacher(),
+		}
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/v1/characters/", nil)
+		require.NoError(t, err)
+		r := mux.SetURLVars(req, map[string]string{
+			"id": strconv.Itoa(id),
+		})
+		s.GetCharacterInfo(rec, r)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		_, ok := s.cacher.Get(buildCharacterInfoCacheKey(id))
+		require.False(t, ok)
+	})
+	t.Run("not_found_cached", func(t *testing.T) {
+		t.Parallel()
+		id := 1
+		testServer, err := test.NewTestServer(test.NewMockHandler(`{"code":404,"status":"We couldn't find that character"}`))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		s := &Server{
+			marvelAPI:   api,
+			cacher:      cacher.NewCacher(),
+			notFoundTTL: time.Minute,
+		}
+		req, err := http.NewRequest(http.MethodGet, "/v1/characters/", nil)
+		require.NoError(t, err)
+		r := mux.SetURLVars(req, map[string]string{
+			"id": strconv.Itoa(id),
+		})
+		for i := 0; i < 3; i++ {
+			rec := httptest.NewRecorder()
+			s.GetCharacterInfo(rec, r)
+			require.Equal(t, http.StatusNotFound, rec.Code)
+		}
+		require.Equal(t, uint64(1), api.Stats().Requests)
+
+		// the character got created, the sync replaces the not found entry
+		s.storeCharacterRecords([]*marvel.MarvelCharacter{{ID: id, Name: "created"}})
+		rec := httptest.NewRecorder()
+		s.GetCharacterInfo(rec, r)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"id":1,"name":"created","description":""}`, rec.Body.String())
+	})
+	t.Run("transient_error_not_cached", func(t *testing.T) {
+		t.Parallel()
+		id := 1
+		testServer, err := test.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "",
+			marvel.WithScheme("http"),
+			marvel.WithRetryPolicy(marvel.RetryPolicy{MaxAttempts: 1}),
+		)
+		s := &Server{
+			marvelAPI:   api,
+			cacher:      cacher.NewCacher(),
+			notFoundTTL: time.Minute,
+		}
+		req, err := http.NewRequest(http.MethodGet, "/v1/characters/", nil)
+		require.NoError(t, err)
+		r := mux.SetURLVars(req, map[string]string{
+			"id": strconv.Itoa(id),
+		})
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			s.GetCharacterInfo(rec, r)
+			require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		}
+		require.Equal(t, uint64(2), api.Stats().Requests)
+		_, ok := s.cacher.Get(buildCharacterInfoCacheKey(id))
+		require.False(t, ok)
+	})
 	t.Run("stress", func(t *testing.T) {
 		t.Parallel()
 		id := 1011334
 		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
 		require.NoError(t, err)
-		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "")
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
 		s := &Server{
 			marvelAPI: api,
 			cacher:    cacher.NewCacher(),
@@ -180,3 +338,79 @@ func TestGetCharacterInfo(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestGetCharacterDetail(t *testing.T) {
+	t.Parallel()
+	t.Run("invalid_id", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{
+			marvelAPI: marvel.NewAPI("", "", ""),
+			cacher:    cacher.NewCacher(),
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/characters/abc/detail", nil)
+		s.GetCharacterDetail(rec, mux.SetURLVars(req, map[string]string{"id": "abc"}))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("uncached", func(t *testing.T) {
+		t.Parallel()
+		id := 1011334
+		testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleJsonFromMarvel))
+		require.NoError(t, err)
+		api := marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http"))
+		s := &Server{
+			marvelAPI: api,
+			cacher:    cacher.NewCacher(),
+		}
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/characters/1011334/detail", nil)
+			s.GetCharacterDetail(rec, mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)}))
+			require.Equal(t, http.StatusOK, rec.Code)
+			var result marvel.Character
+			err = json.NewDecoder(rec.Body).Decode(&result)
+			require.NoError(t, err)
+			require.Equal(t, id, result.ID)
+			require.Equal(t, "3-D Man", result.Name)
+			require.Equal(t, "jpg", result.Thumbnail.Extension)
+			require.Len(t, result.Comics.Items, 12)
+			require.Len(t, result.URLs, 3)
+		}
+		require.Equal(t, uint64(1), api.Stats().Requests)
+		_, ok := s.cacher.Get(buildCharacterDetailCacheKey(id))
+		require.True(t, ok)
+	})
+}
+
+// ttlCacher records the ttl every key is set with
+type ttlCacher struct {
+	cacher.Cacher
+	lock sync.Mutex
+	ttls map[string]time.Duration
+}
+
+func (c *ttlCacher) SetWithTTL(key, value string, ttl time.Duration) {
+	c.lock.Lock()
+	c.ttls[key] = ttl
+	c.lock.Unlock()
+	c.Cacher.SetWithTTL(key, value, ttl)
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+	testServer, err := test.NewTestServer(test.NewMockHandler(test.SampleAllData1stCall))
+	require.NoError(t, err)
+	c := &ttlCacher{Cacher: cacher.NewCacher(), ttls: make(map[string]time.Duration)}
+	s := &Server{
+		marvelAPI: marvel.NewAPI(test.GetHost(testServer.URL), "", "", marvel.WithScheme("http")),
+		cacher:    c,
+	}
+	s.GetListCharacters(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/characters", nil))
+	req := httptest.NewRequest(http.MethodGet, "/characters/1011334/detail", nil)
+	s.GetCharacterDetail(httptest.NewRecorder(), mux.SetURLVars(req, map[string]string{"id": "1011334"}))
+	// the keys are kept past their ttl to be served stale while they are refreshed
+	require.Equal(t, Character_List_Cache_TTL+Stale_While_Revalidate, c.ttls[Characters_Cache_Key])
+	require.Equal(t, Character_List_Cache_TTL+Stale_While_Revalidate, c.ttls[Characters_Summary_Cache_Key])
+	require.Equal(t, Detail_Cache_TTL+Stale_While_Revalidate, c.ttls[buildCharacterInfoCacheKey(1011334)])
+	require.Equal(t, Detail_Cache_TTL+Stale_While_Revalidate, c.ttls[buildCharacterDetailCacheKey(1011334)])
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+)
+
+// loadSnapshot warms the cache with the snapshot saved by a previous run
+// a missing or corrupted snapshot only means a cold start
+// snapshots are disabled if the cache backend doesn't support them
+func (s *Server) loadSnapshot() {
+	if s.snapshotPath == "" {
+		return
+	}
+	if _, ok := s.cacher.(cacher.Snapshotter); !ok {
+		// log error
+		log.Println("cache snapshot disabled", cacher.ErrSnapshotUnsupported)
+		s.snapshotPath = ""
+		return
+	}
+	n, err := cacher.LoadSnapshot(s.cacher, s.snapshotPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			// log error
+			log.Println("load cache snapshot error", err)
+		}
+		return
+	}
+	log.Println("restored cache snapshot keys", n)
+}
+
+// saveSnapshot saves the snapshot of the cache for the next run
+func (s *Server) saveSnapshot() {
+	if s.snapshotPath == "" {
+		return
+	}
+	if err := cacher.SaveSnapshot(s.cacher, s.snapshotPath); err != nil {
+		// log error
+		log.Println("save cache snapshot error", err)
+	}
+}
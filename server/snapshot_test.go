@@ -0,0 +1,48 @@
+package server
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hauxe/xendit_pratice/cacher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	t.Run("save_and_load", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "cache.snapshot")
+		s := &Server{cacher: cacher.NewCacher(), snapshotPath: path}
+		s.cacher.SetWithTTL(Characters_Cache_Key, "[1,2]", Character_List_Cache_TTL)
+		s.saveSnapshot()
+
+		restarted := &Server{cacher: cacher.NewCacher(), snapshotPath: path}
+		restarted.loadSnapshot()
+		v, ok := restarted.cacher.Get(Characters_Cache_Key)
+		require.True(t, ok)
+		require.Equal(t, "[1,2]", v)
+	})
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: cacher.NewCacher(), snapshotPath: filepath.Join(t.TempDir(), "missing")}
+		s.loadSnapshot()
+		require.NotEmpty(t, s.snapshotPath)
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "cache.snapshot")
+		require.NoError(t, ioutil.WriteFile(path, []byte("corrupted"), 0600))
+		s := &Server{cacher: cacher.NewCacher(), snapshotPath: path}
+		s.loadSnapshot()
+		_, ok := s.cacher.Get(Characters_Cache_Key)
+		require.False(t, ok)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		s := &Server{cacher: cacher.NewRedisCacher("127.0.0.1:0"), snapshotPath: "cache.snapshot"}
+		s.loadSnapshot()
+		require.Empty(t, s.snapshotPath)
+	})
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/hauxe/xendit_pratice/marvel"
+)
+
+const (
+	Stories_Cache_Key           = "stories"
+	Story_Cache_Key             = "story"
+	Character_Stories_Cache_Key = "character_stories"
+)
+
+// stories is the stories resource of marvel api
+func (s *Server) stories() marvelResource {
+	return marvelResource{
+		name:              "story",
+		listCacheKey:      Stories_Cache_Key,
+		detailCacheKey:    Story_Cache_Key,
+		characterCacheKey: Character_Stories_Cache_Key,
+		newList: func() interface{} {
+			return new(marvel.StoryList)
+		},
+		newDetail: func() interface{} {
+			return new(marvel.Story)
+		},
+		parseFilter: func(query url.Values) (resourceFilter, error) {
+			return parseStoryFilter(query)
+		},
+		list: func(ctx context.Context, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListStoriesWithContext(ctx, filter.(marvel.StoryFilter))
+		},
+		get: func(ctx context.Context, id int) (interface{}, error) {
+			return s.marvelAPI.GetStoryWithContext(ctx, id)
+		},
+		listByCharacter: func(ctx context.Context, charID int, filter resourceFilter) (interface{}, error) {
+			return s.marvelAPI.ListCharacterStoriesWithContext(ctx, charID, filter.(marvel.StoryFilter))
+		},
+	}
+}
+
+// ListStories responds a page of stories matching the marvel filters in query parameters
+func (s *Server) ListStories(w http.ResponseWriter, r *http.Request) {
+	s.listResource(w, r, s.stories())
+}
+
+// GetStory responds the story by id
+func (s *Server) GetStory(w http.ResponseWriter, r *http.Request) {
+	s.getResource(w, r, s.stories())
+}
+
+// ListCharacterStories responds a page of stories of the character
+func (s *Server) ListCharacterStories(w http.ResponseWriter, r *http.Request) {
+	s.listCharacterResource(w, r, s.stories())
+}
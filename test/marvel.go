@@ -30,9 +30,37 @@ func NewMockHandler(json string) http.Handler {
 	m := &MockHandler{
 		json: json,
 	}
+	return newMockRouter(m.GetListCharacters, m.GetCharacterInfo)
+}
+
+// RoundTripperFunc is an adapter to use a function as http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// NewMockTransport creates a transport serving requests directly by handler without any network
+func NewMockTransport(handler http.Handler) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		resp := rec.Result()
+		resp.Request = r
+		return resp, nil
+	})
+}
+
+// newMockRouter routes every marvel resource: lists to list handler, single resources to get handler
+func newMockRouter(list, get http.HandlerFunc) http.Handler {
 	router := mux.NewRouter()
-	router.Path("/v1/public/characters").HandlerFunc(m.GetListCharacters)
-	router.Path("/v1/public/characters/{id:[0-9]+}").HandlerFunc(m.GetCharacterInfo)
+	router.Path("/v1/public/{resource}").HandlerFunc(list)
+	router.Path("/v1/public/{resource}/{id:[0-9]+}").HandlerFunc(get)
+	router.Path("/v1/public/{resource}/{id:[0-9]+}/{sub}").HandlerFunc(list)
 	return router
 }
 
@@ -63,10 +91,7 @@ func NewMockFlakyHandler(json string, index int) http.Handler {
 		},
 		flakyIndex: index,
 	}
-	router := mux.NewRouter()
-	router.Path("/v1/public/characters").HandlerFunc(m.GetListCharacters)
-	router.Path("/v1/public/characters/{id:[0-9]+}").HandlerFunc(m.GetCharacterInfo)
-	return router
+	return newMockRouter(m.GetListCharacters, m.GetCharacterInfo)
 }
 
 func (h *mockFlakyError) GetListCharacters(w http.ResponseWriter, r *http.Request) {
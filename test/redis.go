@@ -0,0 +1,292 @@
+/**
+Test Utils for redis cache
+*/
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisServer is an in-process stand-in of a redis server
+// it supports PING, AUTH, GET, SET (with EX and PX), DEL, PTTL, SCAN (with MATCH) and FLUSHDB
+type RedisServer struct {
+	listener net.Listener
+	password string
+
+	lock     sync.Mutex
+	data     map[string]redisValue
+	conns    map[net.Conn]struct{}
+	commands int
+	wg       sync.WaitGroup
+}
+
+type redisValue struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewRedisServer starts a redis stand-in listening on a random local port
+// with a non empty password, the connections must AUTH first
+func NewRedisServer(password string) (*RedisServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &RedisServer{
+		listener: listener,
+		password: password,
+		data:     make(map[string]redisValue),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr is the address the server listens on
+func (s *RedisServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Commands is the number of commands the server received
+func (s *RedisServer) Commands() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.commands
+}
+
+// Get reads a key directly from the server storage
+func (s *RedisServer) Get(key string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	v, ok := s.lookup(key)
+	return v.value, ok
+}
+
+// Close stops the server and drops all the connections
+func (s *RedisServer) Close() {
+	s.listener.Close()
+	s.lock.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+func (s *RedisServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.lock.Lock()
+		s.conns[conn] = struct{}{}
+		s.lock.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *RedisServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authenticated := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "AUTH":
+			if len(args) == 2 && args[1] == s.password {
+				authenticated = true
+				w.WriteString("+OK\r\n")
+			} else {
+				w.WriteString("-WRONGPASS invalid password\r\n")
+			}
+		case !authenticated:
+			w.WriteString("-NOAUTH Authentication required.\r\n")
+		default:
+			s.exec(w, name, args[1:])
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *RedisServer) exec(w *bufio.Writer, name string, args []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.commands++
+	switch name {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "GET":
+		if len(args) != 1 {
+			writeRedisError(w, name)
+			return
+		}
+		v, ok := s.lookup(args[0])
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		writeBulk(w, v.value)
+	case "SET":
+		if len(args) != 2 && len(args) != 4 {
+			writeRedisError(w, name)
+			return
+		}
+		v := redisValue{value: args[1]}
+		if len(args) == 4 {
+			n, err := strconv.ParseInt(args[3], 10, 64)
+			if err != nil || n <= 0 {
+				w.WriteString("-ERR invalid expire time in 'set' command\r\n")
+				return
+			}
+			switch strings.ToUpper(args[2]) {
+			case "EX":
+				v.expiresAt = time.Now().Add(time.Duration(n) * time.Second)
+			case "PX":
+				v.expiresAt = time.Now().Add(time.Duration(n) * time.Millisecond)
+			default:
+				w.WriteString("-ERR syntax error\r\n")
+				return
+			}
+		}
+		s.data[args[0]] = v
+		w.WriteString("+OK\r\n")
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := s.lookup(key); ok {
+				delete(s.data, key)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "PTTL":
+		if len(args) != 1 {
+			writeRedisError(w, name)
+			return
+		}
+		v, ok := s.lookup(args[0])
+		switch {
+		case !ok:
+			w.WriteString(":-2\r\n")
+		case v.expiresAt.IsZero():
+			w.WriteString(":-1\r\n")
+		default:
+			fmt.Fprintf(w, ":%d\r\n", time.Until(v.expiresAt).Milliseconds())
+		}
+	case "SCAN":
+		s.scan(w, args)
+	case "FLUSHDB":
+		s.data = make(map[string]redisValue)
+		w.WriteString("+OK\r\n")
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", name)
+	}
+}
+
+// scan returns all the matching keys at once, the cursor is always 0
+func (s *RedisServer) scan(w *bufio.Writer, args []string) {
+	pattern := "*"
+	for i := 1; i+1 < len(args); i += 2 {
+		if strings.ToUpper(args[i]) == "MATCH" {
+			pattern = args[i+1]
+		}
+	}
+	var keys []string
+	for key := range s.data {
+		if _, ok := s.lookup(key); !ok {
+			continue
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	w.WriteString("*2\r\n")
+	writeBulk(w, "0")
+	fmt.Fprintf(w, "*%d\r\n", len(keys))
+	for _, key := range keys {
+		writeBulk(w, key)
+	}
+}
+
+// lookup gets a key, expired keys are removed
+func (s *RedisServer) lookup(key string) (redisValue, bool) {
+	v, ok := s.data[key]
+	if !ok {
+		return v, false
+	}
+	if !v.expiresAt.IsZero() && !time.Now().Before(v.expiresAt) {
+		delete(s.data, key)
+		return v, false
+	}
+	return v, true
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(line, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeRedisError(w *bufio.Writer, name string) {
+	fmt.Fprintf(w, "-ERR wrong number of arguments for '%s' command\r\n", strings.ToLower(name))
+}
@@ -285,3 +285,281 @@ var SampleAllData1stCall = `{
 		]
 	}
 }`
+
+var SampleComicJson = `{
+	"code": 200,
+	"status": "Ok",
+	"data": {
+		"offset": 0,
+		"limit": 20,
+		"total": 1,
+		"count": 1,
+		"results": [{
+			"id": 21366,
+			"digitalId": 0,
+			"title": "Avengers: The Initiative (2007) #14",
+			"issueNumber": 14,
+			"variantDescription": "",
+			"description": "test comic description",
+			"modified": "2015-10-27T16:38:23-0400",
+			"isbn": "",
+			"upc": "5960606084-01411",
+			"diamondCode": "",
+			"ean": "",
+			"issn": "",
+			"format": "Comic",
+			"pageCount": 32,
+			"textObjects": [{
+				"type": "issue_solicit_text",
+				"language": "en-us",
+				"text": "test comic description"
+			}],
+			"resourceURI": "http://gateway.marvel.com/v1/public/comics/21366",
+			"urls": [{
+				"type": "detail",
+				"url": "http://marvel.com/comics/issue/21366/avengers_the_initiative_2007_14"
+			}],
+			"series": {
+				"resourceURI": "http://gateway.marvel.com/v1/public/series/1945",
+				"name": "Avengers: The Initiative (2007 - 2010)"
+			},
+			"variants": [{
+				"resourceURI": "http://gateway.marvel.com/v1/public/comics/24571",
+				"name": "Avengers: The Initiative (2007) #14 (SPOTLIGHT VARIANT)"
+			}],
+			"collections": [],
+			"collectedIssues": [],
+			"dates": [{
+				"type": "onsaleDate",
+				"date": "2008-06-25T00:00:00-0400"
+			}],
+			"prices": [{
+				"type": "printPrice",
+				"price": 2.99
+			}],
+			"thumbnail": {
+				"path": "http://i.annihil.us/u/prod/marvel/i/mg/c/80/5e3d7536c8ada",
+				"extension": "jpg"
+			},
+			"images": [{
+				"path": "http://i.annihil.us/u/prod/marvel/i/mg/c/80/5e3d7536c8ada",
+				"extension": "jpg"
+			}],
+			"creators": {
+				"available": 2,
+				"collectionURI": "http://gateway.marvel.com/v1/public/comics/21366/creators",
+				"items": [{
+						"resourceURI": "http://gateway.marvel.com/v1/public/creators/1133",
+						"name": "Dan Slott",
+						"role": "writer"
+					},
+					{
+						"resourceURI": "http://gateway.marvel.com/v1/public/creators/648",
+						"name": "Stefano Caselli",
+						"role": "penciller (cover)"
+					}
+				],
+				"returned": 2
+			},
+			"characters": {
+				"available": 2,
+				"collectionURI": "http://gateway.marvel.com/v1/public/comics/21366/characters",
+				"items": [{
+						"resourceURI": "http://gateway.marvel.com/v1/public/characters/1011334",
+						"name": "3-D Man"
+					},
+					{
+						"resourceURI": "http://gateway.marvel.com/v1/public/characters/1009165",
+						"name": "Avengers"
+					}
+				],
+				"returned": 2
+			},
+			"stories": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/comics/21366/stories",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/stories/47184",
+					"name": "AVENGERS: THE INITIATIVE (2007) #14",
+					"type": "cover"
+				}],
+				"returned": 1
+			},
+			"events": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/comics/21366/events",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/events/269",
+					"name": "Secret Invasion"
+				}],
+				"returned": 1
+			}
+		}]
+	}
+}`
+
+var SampleSeriesJson = `{
+	"code": 200,
+	"status": "Ok",
+	"data": {
+		"offset": 0,
+		"limit": 20,
+		"total": 1,
+		"count": 1,
+		"results": [{
+			"id": 1945,
+			"title": "Avengers: The Initiative (2007 - 2010)",
+			"description": null,
+			"resourceURI": "http://gateway.marvel.com/v1/public/series/1945",
+			"urls": [{
+				"type": "detail",
+				"url": "http://marvel.com/comics/series/1945/avengers_the_initiative_2007_-_2010"
+			}],
+			"startYear": 2007,
+			"endYear": 2010,
+			"rating": "T",
+			"type": "",
+			"modified": "2013-03-05T10:11:26-0500",
+			"thumbnail": {
+				"path": "http://i.annihil.us/u/prod/marvel/i/mg/5/a0/514a2ed3302f5",
+				"extension": "jpg"
+			},
+			"creators": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"characters": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/series/1945/characters",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/characters/1011334",
+					"name": "3-D Man"
+				}],
+				"returned": 1
+			},
+			"stories": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"comics": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"events": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"next": null,
+			"previous": null
+		}]
+	}
+}`
+
+var SampleEventJson = `{
+	"code": 200,
+	"status": "Ok",
+	"data": {
+		"offset": 0,
+		"limit": 20,
+		"total": 1,
+		"count": 1,
+		"results": [{
+			"id": 269,
+			"title": "Secret Invasion",
+			"description": "The shape-shifting Skrulls have been infiltrating the Earth for years.",
+			"resourceURI": "http://gateway.marvel.com/v1/public/events/269",
+			"urls": [],
+			"modified": "2013-06-28T16:31:24-0400",
+			"start": "2008-06-02 00:00:00",
+			"end": "2009-01-25 00:00:00",
+			"thumbnail": {
+				"path": "http://i.annihil.us/u/prod/marvel/i/mg/6/70/51ca1749980ae",
+				"extension": "jpg"
+			},
+			"creators": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"characters": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/events/269/characters",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/characters/1011334",
+					"name": "3-D Man"
+				}],
+				"returned": 1
+			},
+			"stories": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"comics": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"series": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"next": {
+				"resourceURI": "http://gateway.marvel.com/v1/public/events/318",
+				"name": "Dark Reign"
+			},
+			"previous": {
+				"resourceURI": "http://gateway.marvel.com/v1/public/events/255",
+				"name": "Initiative"
+			}
+		}]
+	}
+}`
+
+var SampleStoryJson = `{
+	"code": 200,
+	"status": "Ok",
+	"data": {
+		"offset": 0,
+		"limit": 20,
+		"total": 1,
+		"count": 1,
+		"results": [{
+			"id": 19947,
+			"title": "Cover #19947",
+			"description": "",
+			"resourceURI": "http://gateway.marvel.com/v1/public/stories/19947",
+			"type": "cover",
+			"modified": "1969-12-31T19:00:00-0500",
+			"thumbnail": null,
+			"creators": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"characters": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/stories/19947/characters",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/characters/1011334",
+					"name": "3-D Man"
+				}],
+				"returned": 1
+			},
+			"series": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"comics": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"events": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"originalIssue": {
+				"resourceURI": "http://gateway.marvel.com/v1/public/comics/10223",
+				"name": "Marvel Premiere (1972) #35"
+			}
+		}]
+	}
+}`
+
+var SampleCreatorJson = `{
+	"code": 200,
+	"status": "Ok",
+	"data": {
+		"offset": 0,
+		"limit": 20,
+		"total": 1,
+		"count": 1,
+		"results": [{
+			"id": 1133,
+			"firstName": "Dan",
+			"middleName": "",
+			"lastName": "Slott",
+			"suffix": "",
+			"fullName": "Dan Slott",
+			"modified": "2013-01-14T10:51:28-0500",
+			"thumbnail": {
+				"path": "http://i.annihil.us/u/prod/marvel/i/mg/f/30/4c7f1d1a5c3d2",
+				"extension": "jpg"
+			},
+			"resourceURI": "http://gateway.marvel.com/v1/public/creators/1133",
+			"comics": {
+				"available": 1,
+				"collectionURI": "http://gateway.marvel.com/v1/public/creators/1133/comics",
+				"items": [{
+					"resourceURI": "http://gateway.marvel.com/v1/public/comics/21366",
+					"name": "Avengers: The Initiative (2007) #14"
+				}],
+				"returned": 1
+			},
+			"series": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"stories": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"events": {"available": 0, "collectionURI": "", "items": [], "returned": 0},
+			"urls": []
+		}]
+	}
+}`